@@ -0,0 +1,129 @@
+package go_deep_copy
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// Allocator provides the memory a Copier writes new pointer targets, slice
+// backing arrays and values boxed into interfaces into. Maps are always
+// allocated by the runtime, and so are the temporary keys and elements copied
+// into a map.
+//
+// Alloc returns a pointer to n contiguous zero values of typ. The memory must
+// be visible to the garbage collector as values of typ, for example the
+// backing array of a []typ, and Alloc must be safe for concurrent use. An
+// Allocator that reuses memory, such as one backed by a sync.Pool, is only
+// safe when the caller knows the copies are no longer used.
+type Allocator interface {
+	Alloc(typ reflect.Type, n int) unsafe.Pointer
+}
+
+// WithAllocator makes the Copier allocate through a instead of allocating
+// each value on the heap.
+func WithAllocator(a Allocator) Option {
+	return func(c *Copier) {
+		c.allocator = a
+	}
+}
+
+// NewSlabAllocator returns an Allocator carving values out of slabs of size
+// values per type, so a clone made of many small structs and pointers costs a
+// few large allocations. A slab is freed once no value in it is referenced, so
+// a long-lived copy may keep its whole slab alive.
+//
+// Carving a value out of a slab costs about as much as a small heap
+// allocation, so a slab does not make a copy faster. It cuts the number of
+// allocations of values made of many pointers, such as a []*T or a tree of
+// nodes, and gains nothing for a struct holding a few slices.
+func NewSlabAllocator(size int) Allocator {
+	if size < 1 {
+		size = 1
+	}
+	a := &slabAllocator{size: size}
+	a.slabs.Store(map[reflect.Type]*slab{})
+	return a
+}
+
+// slabAllocator 按类型维护当前的 slab，slab 为 []typ 的底层数组，GC 可以正确扫描其中的指针；
+// slabs 为写时复制的 map[reflect.Type]*slab，分配只需一次无锁查找与一次原子加法，slab 用完时才加锁换上新的 slab
+type slabAllocator struct {
+	mu    sync.Mutex
+	size  int
+	slabs atomic.Value
+}
+
+type slab struct {
+	base     unsafe.Pointer
+	elemSize uintptr
+	// used 为已分配出去的值的个数，并发分配越过 cap 时说明 slab 已用完
+	used int64
+	cap  int64
+}
+
+func (a *slabAllocator) Alloc(typ reflect.Type, n int) unsafe.Pointer {
+	if n > a.size {
+		// 超过 slab 大小的数组单独分配
+		return unsafe.Pointer(reflect.MakeSlice(reflect.SliceOf(typ), n, n).Pointer())
+	}
+	for {
+		s := a.slabs.Load().(map[reflect.Type]*slab)[typ]
+		if s != nil {
+			if end := atomic.AddInt64(&s.used, int64(n)); end <= s.cap {
+				return unsafe.Pointer(uintptr(s.base) + uintptr(end-int64(n))*s.elemSize)
+			}
+		}
+		a.refill(typ, s)
+	}
+}
+
+// refill 在 typ 当前的 slab 仍为 old 时换上新的 slab，其他 goroutine 已换过时直接返回
+func (a *slabAllocator) refill(typ reflect.Type, old *slab) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	slabs := a.slabs.Load().(map[reflect.Type]*slab)
+	if slabs[typ] != old {
+		return
+	}
+	next := make(map[reflect.Type]*slab, len(slabs)+1)
+	for k, v := range slabs {
+		next[k] = v
+	}
+	next[typ] = &slab{
+		base:     unsafe.Pointer(reflect.MakeSlice(reflect.SliceOf(typ), a.size, a.size).Pointer()),
+		elemSize: typ.Size(),
+		cap:      int64(a.size),
+	}
+	a.slabs.Store(next)
+}
+
+// sliceHeader 与 reflect.SliceHeader 相同，Data 为 unsafe.Pointer 以保持底层数组可达
+type sliceHeader struct {
+	Data unsafe.Pointer
+	Len  int
+	Cap  int
+}
+
+// newValue 返回 typ 的新零值，用作目标中新的指针指向的值
+func (c *Copier) newValue(typ reflect2.Type) unsafe.Pointer {
+	if c.allocator == nil {
+		return typ.UnsafeNew()
+	}
+	return c.allocator.Alloc(typ.Type1(), 1)
+}
+
+// makeSlice 返回长度与容量均为 length 的新切片的指针；容量不超过长度，append 不会写到 slab 中相邻的值
+func (c *Copier) makeSlice(typ *reflect2.UnsafeSliceType, length int) unsafe.Pointer {
+	if c.allocator == nil {
+		return typ.UnsafeMakeSlice(length, length)
+	}
+	return unsafe.Pointer(&sliceHeader{
+		Data: c.allocator.Alloc(typ.Elem().Type1(), length),
+		Len:  length,
+		Cap:  length,
+	})
+}
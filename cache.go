@@ -0,0 +1,236 @@
+package go_deep_copy
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// CacheStatistics reports the state of the caches of a Copier.
+type CacheStatistics struct {
+	// Hits and Misses count convert func lookups.
+	Hits   uint64
+	Misses uint64
+	// Compilations counts convert funcs built by the planner.
+	Compilations uint64
+	// Evictions counts convert funcs, struct descriptors and field masks dropped to respect the cache limit.
+	Evictions uint64
+	// Funcs, Structs and Masks are the number of cached convert funcs, struct
+	// descriptors and field masks of DeepCopyWithOptions.
+	Funcs   int
+	Structs int
+	Masks   int
+}
+
+// WithCacheLimit bounds the number of convert funcs, of struct descriptors and
+// of field masks cached by the Copier. 0, the default, means unbounded.
+func WithCacheLimit(limit int) Option {
+	return func(c *Copier) {
+		c.cache.limit = int64(limit)
+	}
+}
+
+// SetCacheLimit bounds the caches used by DeepCopy and Clone, see WithCacheLimit.
+func SetCacheLimit(limit int) {
+	defaultCopier.cache.setLimit(limit)
+	cloneCopier.cache.setLimit(limit)
+}
+
+// CacheStats returns the cache statistics of DeepCopy and Clone.
+func CacheStats() CacheStatistics {
+	s, cs := defaultCopier.CacheStats(), cloneCopier.CacheStats()
+	return CacheStatistics{
+		Hits:         s.Hits + cs.Hits,
+		Misses:       s.Misses + cs.Misses,
+		Compilations: s.Compilations + cs.Compilations,
+		Evictions:    s.Evictions + cs.Evictions,
+		Funcs:        s.Funcs + cs.Funcs,
+		Structs:      s.Structs + cs.Structs,
+		Masks:        s.Masks + cs.Masks,
+	}
+}
+
+// ResetCache drops everything cached by DeepCopy and Clone and zeroes the statistics.
+func ResetCache() {
+	defaultCopier.ResetCache()
+	cloneCopier.ResetCache()
+}
+
+// CacheStats returns the cache statistics of c.
+func (c *Copier) CacheStats() CacheStatistics {
+	return c.cache.stats()
+}
+
+// ResetCache drops everything cached by c and zeroes the statistics.
+func (c *Copier) ResetCache() {
+	c.cache.reset()
+}
+
+// cacheEntry 包装缓存值，used 记录自上次淘汰以来是否被访问过（second chance）
+type cacheEntry struct {
+	value any
+	used  uint32
+}
+
+func newCacheEntry(v any) *cacheEntry {
+	return &cacheEntry{value: v, used: 1}
+}
+
+func (e *cacheEntry) touch() any {
+	if atomic.LoadUint32(&e.used) == 0 {
+		atomic.StoreUint32(&e.used, 1)
+	}
+	return e.value
+}
+
+// planCache 缓存转换函数、结构体描述与字段掩码，超过容量上限时淘汰最近未访问的条目
+type planCache struct {
+	limit int64
+
+	funcs   *MapRCU
+	structs *LinerRCU
+	// masks 按路径集合缓存字段掩码，只在 DeepCopyWithOptions 中使用，不需要无锁读取
+	masksMu sync.RWMutex
+	masks   map[string]*cacheEntry
+
+	hits         uint64
+	misses       uint64
+	compilations uint64
+	evictions    uint64
+}
+
+func newPlanCache() *planCache {
+	return &planCache{
+		funcs:   NewMapRCU(),
+		structs: NewLinerRCU(),
+		masks:   map[string]*cacheEntry{},
+	}
+}
+
+func (p *planCache) setLimit(limit int) {
+	atomic.StoreInt64(&p.limit, int64(limit))
+	p.shrink(p.funcs.Len, p.funcs.Evict)
+	p.shrink(p.structs.Len, p.structs.Evict)
+	p.shrink(p.maskLen, p.evictMasks)
+}
+
+func (p *planCache) loadFunc(key [2]uintptr) (ConvertFunc, bool) {
+	if v, ok := p.funcs.Load(key); ok {
+		atomic.AddUint64(&p.hits, 1)
+		return v.(*cacheEntry).touch().(ConvertFunc), true
+	}
+	atomic.AddUint64(&p.misses, 1)
+	return nil, false
+}
+
+func (p *planCache) loadOrStoreFunc(key [2]uintptr, f ConvertFunc) (ConvertFunc, bool) {
+	v, loaded := p.funcs.LoadOrStore(key, newCacheEntry(f))
+	if loaded {
+		return v.(*cacheEntry).touch().(ConvertFunc), true
+	}
+	p.shrink(p.funcs.Len, p.funcs.Evict)
+	return f, false
+}
+
+func (p *planCache) storeFunc(key [2]uintptr, f ConvertFunc) {
+	atomic.AddUint64(&p.compilations, 1)
+	p.funcs.Store(key, newCacheEntry(f))
+}
+
+func (p *planCache) loadStruct(vt reflect2.Type) (StructDescriptor, bool) {
+	if v, ok := p.structs.Load(vt); ok {
+		return v.(*cacheEntry).touch().(StructDescriptor), true
+	}
+	return StructDescriptor{}, false
+}
+
+func (p *planCache) storeStruct(vt reflect2.Type, structInfo StructDescriptor) {
+	p.structs.Store(vt, newCacheEntry(structInfo))
+	p.shrink(p.structs.Len, p.structs.Evict)
+}
+
+func (p *planCache) loadMask(key string) (*fieldMask, bool) {
+	p.masksMu.RLock()
+	e, ok := p.masks[key]
+	p.masksMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return e.touch().(*fieldMask), true
+}
+
+// loadOrStoreMask 返回 key 已缓存的字段掩码，没有时缓存 m
+func (p *planCache) loadOrStoreMask(key string, m *fieldMask) *fieldMask {
+	p.masksMu.Lock()
+	if e, ok := p.masks[key]; ok {
+		p.masksMu.Unlock()
+		return e.touch().(*fieldMask)
+	}
+	p.masks[key] = newCacheEntry(m)
+	p.masksMu.Unlock()
+	p.shrink(p.maskLen, p.evictMasks)
+	return m
+}
+
+func (p *planCache) maskLen() int {
+	p.masksMu.RLock()
+	defer p.masksMu.RUnlock()
+	return len(p.masks)
+}
+
+func (p *planCache) evictMasks(keep func(v any) bool) int {
+	p.masksMu.Lock()
+	defer p.masksMu.Unlock()
+	n := 0
+	for key, e := range p.masks {
+		if !keep(e) {
+			delete(p.masks, key)
+			n++
+		}
+	}
+	return n
+}
+
+// shrink 超过上限时先淘汰自上次淘汰以来未被访问的条目，若全部都被访问过则淘汰一半
+func (p *planCache) shrink(size func() int, evict func(keep func(v any) bool) int) {
+	limit := int(atomic.LoadInt64(&p.limit))
+	if limit <= 0 || size() <= limit {
+		return
+	}
+	n := evict(func(v any) bool {
+		return atomic.SwapUint32(&v.(*cacheEntry).used, 0) == 1
+	})
+	if n == 0 {
+		i := 0
+		n = evict(func(v any) bool {
+			i++
+			return i%2 == 0
+		})
+	}
+	atomic.AddUint64(&p.evictions, uint64(n))
+}
+
+func (p *planCache) stats() CacheStatistics {
+	return CacheStatistics{
+		Hits:         atomic.LoadUint64(&p.hits),
+		Misses:       atomic.LoadUint64(&p.misses),
+		Compilations: atomic.LoadUint64(&p.compilations),
+		Evictions:    atomic.LoadUint64(&p.evictions),
+		Funcs:        p.funcs.Len(),
+		Structs:      p.structs.Len(),
+		Masks:        p.maskLen(),
+	}
+}
+
+func (p *planCache) reset() {
+	p.funcs.Reset()
+	p.structs.Reset()
+	p.masksMu.Lock()
+	p.masks = map[string]*cacheEntry{}
+	p.masksMu.Unlock()
+	atomic.StoreUint64(&p.hits, 0)
+	atomic.StoreUint64(&p.misses, 0)
+	atomic.StoreUint64(&p.compilations, 0)
+	atomic.StoreUint64(&p.evictions, 0)
+}
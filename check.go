@@ -0,0 +1,187 @@
+package go_deep_copy
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// Conversion is a field conversion found by Check. Path is the destination path.
+type Conversion struct {
+	Path string
+	From reflect.Type
+	To   reflect.Type
+}
+
+// Report describes, without any value at hand, what copying fromType into
+// toType does. Paths use dots for fields and [*] for slice, array and map
+// elements, "Items[*].SKU" for instance.
+type Report struct {
+	// Filled lists the destination struct fields that get a value.
+	Filled []string
+	// Unfilled lists the destination struct fields no source field maps to
+	// and that have no default value.
+	Unfilled []string
+	// Unmatched lists the source struct fields that are not copied anywhere.
+	Unmatched []string
+	// Lossy lists the conversions that may truncate, round or fail depending
+	// on the value, such as int64 -> int32, float64 -> int or string -> int.
+	Lossy []Conversion
+	// Unsupported lists the conversions that always fail.
+	Unsupported []*PlanError
+}
+
+// FullCoverage reports whether every destination field is filled and no
+// conversion is unsupported.
+func (r Report) FullCoverage() bool {
+	return len(r.Unfilled) == 0 && len(r.Unsupported) == 0
+}
+
+func (r Report) String() string {
+	var b strings.Builder
+	writeList := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		b.WriteString(title)
+		b.WriteString(": ")
+		b.WriteString(strings.Join(items, ", "))
+		b.WriteString("\n")
+	}
+	writeList("filled", r.Filled)
+	writeList("unfilled", r.Unfilled)
+	writeList("unmatched", r.Unmatched)
+	lossy := make([]string, len(r.Lossy))
+	for i, cvt := range r.Lossy {
+		lossy[i] = cvt.Path + " (" + cvt.From.String() + " -> " + cvt.To.String() + ")"
+	}
+	writeList("lossy", lossy)
+	unsupported := make([]string, len(r.Unsupported))
+	for i, err := range r.Unsupported {
+		unsupported[i] = err.Error()
+	}
+	writeList("unsupported", unsupported)
+	return b.String()
+}
+
+// Check reports how DeepCopy converts fromType into toType, see Copier.Check.
+func Check(fromType, toType reflect.Type) Report {
+	return defaultCopier.Check(fromType, toType)
+}
+
+// Check walks the plans c builds to copy fromType into toType and reports
+// which destination fields are filled, which source fields are left out and
+// which conversions are lossy or unsupported. The plans are cached as a side
+// effect. Types only known at run time, such as the dynamic types held by
+// interface{} sources, are not inspected.
+func (c *Copier) Check(fromType, toType reflect.Type) Report {
+	report := &Report{}
+	w := &planWalker{c: c, seen: map[[2]uintptr]bool{}, perPath: true, report: report}
+	w.walk("", "", reflect2.Type2(fromType), reflect2.Type2(toType))
+	report.Unsupported = w.errs
+	return *report
+}
+
+// TestingT is the part of testing.TB used by RequireFullCoverage.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// RequireFullCoverage fails t unless copying fromType into toType fills every
+// destination field without unsupported conversions.
+//
+//	func TestUserDTO(t *testing.T) {
+//		go_deep_copy.RequireFullCoverage(t, reflect.TypeOf(User{}), reflect.TypeOf(UserDTO{}))
+//	}
+func RequireFullCoverage(t TestingT, fromType, toType reflect.Type) {
+	t.Helper()
+	if report := Check(fromType, toType); !report.FullCoverage() {
+		t.Errorf("%s -> %s is not fully covered:\n%s", fromType, toType, report)
+	}
+}
+
+// add 记录 v -> t 这一转换的字段覆盖情况及是否有损
+func (r *Report) add(c *Copier, vPath, tPath string, v, t reflect2.Type) {
+	if isLossy(v, t) {
+		r.Lossy = append(r.Lossy, Conversion{Path: tPath, From: v.Type1(), To: t.Type1()})
+	}
+	if t.Kind() != reflect.Struct || isNoCopyType(v) || isNoCopyType(t) {
+		return
+	}
+	tInfo := c.loadStructFieldsInfo(t)
+	switch v.Kind() {
+	case reflect.Struct:
+		matches, unmatchedV, unmatchedT, _ := c.matchStructFields(v, t)
+		for _, f := range unmatchedV {
+			r.Unmatched = append(r.Unmatched, joinPath(vPath, f.path.name))
+		}
+		for _, m := range matches {
+			r.Filled = append(r.Filled, joinPath(tPath, m.t.name))
+		}
+		for _, tf := range unmatchedT {
+			if tf.defaultValue != nil {
+				r.Filled = append(r.Filled, joinPath(tPath, tf.path.name))
+			} else {
+				r.Unfilled = append(r.Unfilled, joinPath(tPath, tf.path.name))
+			}
+		}
+	case reflect.Map:
+		// map 的键在拷贝时才能确定，目标字段均视为可填充
+		if v.(reflect2.MapType).Key().Kind() != reflect.String {
+			return
+		}
+		for _, tf := range tInfo.Fields {
+			r.Filled = append(r.Filled, joinPath(tPath, tf.Field.Name()))
+		}
+	}
+}
+
+// isLossy 判断 v -> t 是否可能因取值而截断、舍入或失败
+func isLossy(v, t reflect2.Type) bool {
+	vKind, tKind := getKind(v), getKind(t)
+	switch vKind {
+	case reflect.Int, reflect.Uint:
+		switch tKind {
+		case reflect.Int, reflect.Uint:
+			vBits, tBits := v.Type1().Bits(), t.Type1().Bits()
+			if vKind == reflect.Int && tKind == reflect.Uint {
+				return true
+			}
+			if vKind == reflect.Uint && tKind == reflect.Int {
+				return tBits <= vBits
+			}
+			return tBits < vBits
+		case reflect.Float32:
+			mantissa := 53
+			if t.Kind() == reflect.Float32 {
+				mantissa = 24
+			}
+			return v.Type1().Bits() > mantissa
+		case reflect.Bool:
+			return true
+		}
+	case reflect.Float32:
+		switch tKind {
+		case reflect.Int, reflect.Uint, reflect.Bool:
+			return true
+		case reflect.Float32:
+			return t.Type1().Bits() < v.Type1().Bits()
+		}
+	case reflect.Complex64, reflect.Complex128:
+		if tKind == reflect.Complex64 || tKind == reflect.Complex128 {
+			return t.Type1().Bits() < v.Type1().Bits()
+		}
+	case reflect.String:
+		switch tKind {
+		case reflect.Int, reflect.Uint, reflect.Float32, reflect.Bool:
+			return true
+		}
+	case reflect.Slice:
+		return tKind == reflect.Array
+	case reflect.Array:
+		return tKind == reflect.Array && t.Type1().Len() < v.Type1().Len()
+	}
+	return false
+}
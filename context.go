@@ -0,0 +1,87 @@
+package go_deep_copy
+
+import (
+	"context"
+
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+)
+
+// ctxCheckInterval 为两次检查 ctx 之间进入的值与拷贝的元素个数
+const ctxCheckInterval = 256
+
+// DeepCopyContext is DeepCopy with the default Copier that stops when ctx is
+// done; see Copier.DeepCopyContext.
+func DeepCopyContext(ctx context.Context, fromValue interface{}, toValue interface{}) error {
+	return defaultCopier.DeepCopyContext(ctx, fromValue, toValue)
+}
+
+// DeepCopyContext deep copies like DeepCopy, checking ctx periodically while
+// walking structs, maps, slices and arrays. When ctx is done it returns a
+// *ContextError holding ctx.Err() and the path reached, so errors.Is(err,
+// context.Canceled) holds for a canceled ctx.
+//
+// The destination is then partially written: struct fields and map entries
+// copied so far are set, while the slice being copied at the path keeps its
+// previous value. Copy into a fresh value and discard it on error.
+func (c *Copier) DeepCopyContext(ctx context.Context, fromValue interface{}, toValue interface{}) error {
+	v, t, err := c.copyValues(ctx, fromValue, toValue)
+	if err != nil {
+		return err
+	}
+	return c.LoadConvertFunc(v.Typ, t.Typ)(v, t)
+}
+
+// ContextError is returned by DeepCopyContext when its context is done before
+// the copy completes. It unwraps to the error of the context.
+type ContextError struct {
+	// Path locates the source value being copied, such as "Items[3].Name";
+	// map keys are written like field names. It is empty for the root value.
+	Path string
+	Err  error
+}
+
+func (e *ContextError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "root"
+	}
+	return "copy stopped at " + path + ": " + e.Err.Error()
+}
+
+func (e *ContextError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ContextError) prependPath(seg string) {
+	e.Path = joinPath(seg, e.Path)
+}
+
+// Tick 在拷贝切片、数组与 map 的每个元素前调用，元素为标量时同样能及时停止
+func (st *copyState) Tick() error {
+	if st.done == nil {
+		return nil
+	}
+	return st.checkContext()
+}
+
+// tick 调用 st 的 Tick，st 为 nil 时直接返回
+func tick(st rt.State) error {
+	if st == nil {
+		return nil
+	}
+	return st.Tick()
+}
+
+// checkContext 每进入或拷贝 ctxCheckInterval 个值检查一次 ctx
+func (st *copyState) checkContext() error {
+	if st.ticks++; st.ticks < ctxCheckInterval {
+		return nil
+	}
+	st.ticks = 0
+	select {
+	case <-st.done:
+		return &ContextError{Err: st.ctx.Err()}
+	default:
+		return nil
+	}
+}
@@ -2,52 +2,51 @@ package go_deep_copy
 
 import (
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/LiZhiqiang0/go_deep_copy/rt"
 	"github.com/LiZhiqiang0/reflect2"
 )
 
-var mFuncMap *MapRCU
-
-// 缓存结构体信息
-var structInfoCache *LinerRCU
-
-func init() {
-	mFuncMap = NewMapRCU()
-	structInfoCache = NewLinerRCU()
-}
-
-type rcuCacheInfo struct {
-	ConvertFunc ConvertFunc
-}
-
 type ConvertFunc func(rt.Value, rt.Value) error
 
+// LoadConvertFunc returns the cached convert func of the default Copier.
 func LoadConvertFunc(v, t reflect2.Type) ConvertFunc {
+	return defaultCopier.LoadConvertFunc(v, t)
+}
+
+// LoadConvertFunc returns the convert func from v to t, compiling and caching it on first use.
+func (c *Copier) LoadConvertFunc(v, t reflect2.Type) ConvertFunc {
+	if c.visitor != nil {
+		// 记录子转换的副本不缓存转换函数，交给原 Copier 编译
+		return c.visitor.c.LoadConvertFunc(v, t)
+	}
 	key := [2]uintptr{v.RType(), t.RType()}
-	if fi, ok := mFuncMap.Load(key); ok {
-		return fi.(rcuCacheInfo).ConvertFunc
+	if fi, ok := c.cache.loadFunc(key); ok {
+		return fi
 	}
 	var (
 		wg sync.WaitGroup
 		f  ConvertFunc
 	)
 	wg.Add(1)
-	fi, loaded := mFuncMap.LoadOrStore(key, rcuCacheInfo{
-		ConvertFunc: func(v, t rt.Value) error {
-			wg.Wait()
-			return f(v, t)
-		},
+	fi, loaded := c.cache.loadOrStoreFunc(key, func(v, t rt.Value) error {
+		wg.Wait()
+		return f(v, t)
 	})
 	if loaded {
-		return fi.(rcuCacheInfo).ConvertFunc
+		return fi
 	}
-	f = convertOp(v, t)
-	wrapFunc := ConvertFunc(func(v rt.Value, t rt.Value) error {
-		if f == nil {
+	op := c.convertOp(v, t)
+	entered := entersState(v, t)
+	// 占位函数与缓存中的最终函数都经过 f，保证 nil 源值的处理一致
+	f = func(v rt.Value, t rt.Value) error {
+		if op == nil {
 			return ErrNotSupported
 		}
 		if v.Typ.UnsafeIsNil(v.Ptr) {
@@ -58,15 +57,24 @@ func LoadConvertFunc(v, t reflect2.Type) ConvertFunc {
 			t.Typ.UnsafeSet(t.Ptr, t.Typ.UnsafeNew())
 			return nil
 		}
-		return f(v, t)
-	})
+		if v.St != nil && entered {
+			return enterState(op, v, t)
+		}
+		return op(v, t)
+	}
 	wg.Done()
-	mFuncMap.Store(key, rcuCacheInfo{ConvertFunc: wrapFunc})
+	c.cache.storeFunc(key, f)
 
-	return wrapFunc
+	return f
 }
 
-func convertOp(v, t reflect2.Type) func(v, t rt.Value) error {
+func (c *Copier) convertOp(v, t reflect2.Type) func(v, t rt.Value) error {
+	if isNoCopyType(v) || isNoCopyType(t) {
+		return c.noCopyOp(v, t)
+	}
+	if t.Kind() == reflect.Interface && t.Type1().NumMethod() > 0 {
+		return c.ifaceOp(v, t)
+	}
 	vKind := getKind(v)
 	tKind := getKind(t)
 	switch vKind {
@@ -135,6 +143,9 @@ func convertOp(v, t reflect2.Type) func(v, t rt.Value) error {
 		case reflect.String:
 			return cvtString
 		case reflect.Int:
+			if t.Type1() == durationType {
+				return cvtStringDuration
+			}
 			return cvtStringInt
 		case reflect.Uint:
 			return cvtStringUint
@@ -150,58 +161,75 @@ func convertOp(v, t reflect2.Type) func(v, t rt.Value) error {
 		case reflect.String:
 			return cvtSliceToString
 		case reflect.Slice:
-			return cvtSliceToSlice
+			c.visitEdge("[*]", "[*]", elemOf(v), elemOf(t))
+			return c.cvtSliceToSlice
 		case reflect.Array:
-			return cvtSliceToArray
+			c.visitEdge("[*]", "[*]", elemOf(v), elemOf(t))
+			return c.cvtSliceToArray
 
 		}
 
 	case reflect.Array:
 		switch tKind {
 		case reflect.Slice:
-			return cvtArrayToSlice
+			c.visitEdge("[*]", "[*]", elemOf(v), elemOf(t))
+			return c.cvtArrayToSlice
 		case reflect.Array:
-			return cvtArray
+			c.visitEdge("[*]", "[*]", elemOf(v), elemOf(t))
+			return c.cvtArray
 
 		}
 	case reflect.Struct:
 		switch tKind {
 		case reflect.Struct:
-			return cvtStructToStruct
+			return c.structToStructOp(v, t, nil)
 
 		case reflect.Map:
-			return cvtStructToMap
+			return c.structToMapOp(v, t)
 		}
 	case reflect.Map:
 		switch tKind {
 		case reflect.Struct:
-			return cvtMapToStruct
+			return c.mapToStructOp(v, t, nil)
 
 		case reflect.Map:
-			return cvtMapToMap
+			vType, tType := v.(reflect2.MapType), t.(reflect2.MapType)
+			c.visitEdge("[key]", "[key]", vType.Key(), tType.Key())
+			c.visitEdge("[*]", "[*]", vType.Elem(), tType.Elem())
+			return c.cvtMapToMap
 		}
 	case reflect.Ptr:
 		switch tKind {
 		case reflect.Ptr:
-			return cvtTToPtr
-		default:
-			return cvtPtrToT
+			c.visitEdge("", "", v.(reflect2.PtrType).Elem(), t.(reflect2.PtrType).Elem())
+			return c.cvtTToPtr
+		case reflect.Interface:
+			if c.interfaceMode == InterfacePreserve {
+				c.visitEdge("", "", v, v)
+				return c.cvtTToIPreserve
+			}
 		}
+		c.visitEdge("", "", v.(reflect2.PtrType).Elem(), t)
+		return c.cvtPtrToT
 	case reflect.Interface:
 		switch tKind {
 		case reflect.Interface:
-			return cvtIToI
+			if op := c.registeredToIfaceOp(v); op != nil {
+				return op
+			}
+			return c.cvtIToI
 		case reflect.Ptr:
-			return cvtTToPtr
+			return c.cvtTToPtr
 		default:
-			return cvtIToT
+			return c.cvtIToT
 		}
 	}
 	if tKind == reflect.Ptr {
-		return cvtTToPtr
+		c.visitEdge("", "", v, t.(reflect2.PtrType).Elem())
+		return c.cvtTToPtr
 	}
 	if tKind == reflect.Interface {
-		return cvtTToI
+		return c.tToIOp(v)
 	}
 	return nil
 }
@@ -425,6 +453,17 @@ func cvtStringInt(v, t rt.Value) error {
 	return nil
 }
 
+// convertOp: String -> time.Duration
+func cvtStringDuration(v, t rt.Value) error {
+	value := *(*string)(v.Ptr)
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*(*time.Duration)(t.Ptr) = duration
+	return nil
+}
+
 // convertOp: String -> uint
 func cvtStringUint(v, t rt.Value) error {
 	value := *(*string)(v.Ptr)
@@ -506,7 +545,7 @@ func cvtStringRunes(v, t rt.Value) error {
 }
 
 // convertOp: []T -> []T
-func cvtSliceToSlice(v, t rt.Value) error {
+func (c *Copier) cvtSliceToSlice(v, t rt.Value) error {
 	vType := v.Typ.(*reflect2.UnsafeSliceType)
 	tType := t.Typ.(*reflect2.UnsafeSliceType)
 	vElemType := v.Typ.(reflect2.SliceType).Elem()
@@ -516,29 +555,65 @@ func cvtSliceToSlice(v, t rt.Value) error {
 		return nil
 	}
 	length := vType.UnsafeLengthOf(v.Ptr)
+	if p := c.parallel; p != nil && length >= p.threshold {
+		if newState, ok := parallelState(v.St); ok {
+			return c.cvtSliceParallel(p, newState, v, t, length)
+		}
+	}
 	tPtr := tType.UnsafeNew()
+	if length > 0 {
+		tPtr = c.makeSlice(tType, length)
+	}
 	for i := 0; i < length; i++ {
-		elemConverter := LoadConvertFunc(vElemType, tElemType)
-		tType.UnsafeGrow(tPtr, i+1)
+		if err := tick(v.St); err != nil {
+			return errAtIndex(err, i)
+		}
+		elemConverter := c.LoadConvertFunc(vElemType, tElemType)
 		tElemPtr := tType.UnsafeGetIndex(tPtr, i)
 		vElemPtr := vType.UnsafeGetIndex(v.Ptr, i)
 		err := elemConverter(rt.Value{
 			Ptr: vElemPtr,
 			Typ: vElemType,
+			St:  v.St,
 		}, rt.Value{
 			Ptr: tElemPtr,
 			Typ: tElemType,
 		})
 		if err != nil {
-			return err
+			return errAtIndex(err, i)
 		}
 	}
 	tType.UnsafeSet(t.Ptr, tPtr)
 	return nil
 }
 
+// cvtSliceParallel 由多个 goroutine 分段拷贝切片，每段使用 newState 返回的状态
+func (c *Copier) cvtSliceParallel(p *parallelism, newState func() rt.State, v, t rt.Value, length int) error {
+	vType := v.Typ.(*reflect2.UnsafeSliceType)
+	tType := t.Typ.(*reflect2.UnsafeSliceType)
+	vElemType := vType.Elem()
+	tElemType := tType.Elem()
+	elemConverter := c.LoadConvertFunc(vElemType, tElemType)
+	tPtr := c.makeSlice(tType, length)
+	err := p.run(length, newState, func(i int, st rt.State) error {
+		return elemConverter(rt.Value{
+			Ptr: vType.UnsafeGetIndex(v.Ptr, i),
+			Typ: vElemType,
+			St:  st,
+		}, rt.Value{
+			Ptr: tType.UnsafeGetIndex(tPtr, i),
+			Typ: tElemType,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	tType.UnsafeSet(t.Ptr, tPtr)
+	return nil
+}
+
 // convertOp: []T -> [N]T
-func cvtSliceToArray(v, t rt.Value) error {
+func (c *Copier) cvtSliceToArray(v, t rt.Value) error {
 	vType := v.Typ.(*reflect2.UnsafeSliceType)
 	tType := t.Typ.(*reflect2.UnsafeArrayType)
 	vElemType := vType.Elem()
@@ -546,18 +621,22 @@ func cvtSliceToArray(v, t rt.Value) error {
 	vLength := vType.UnsafeLengthOf(v.Ptr)
 	tLength := tType.Len()
 	for i := 0; i < vLength && i < tLength; i++ {
-		elemConverter := LoadConvertFunc(vElemType, tElemType)
+		if err := tick(v.St); err != nil {
+			return errAtIndex(err, i)
+		}
+		elemConverter := c.LoadConvertFunc(vElemType, tElemType)
 		tElemPtr := tType.UnsafeGetIndex(t.Ptr, i)
 		vElemPtr := vType.UnsafeGetIndex(v.Ptr, i)
 		err := elemConverter(rt.Value{
 			Ptr: vElemPtr,
 			Typ: vElemType,
+			St:  v.St,
 		}, rt.Value{
 			Ptr: tElemPtr,
 			Typ: tElemType,
 		})
 		if err != nil {
-			return err
+			return errAtIndex(err, i)
 		}
 	}
 	return nil
@@ -576,27 +655,33 @@ func cvtSliceToString(v, t rt.Value) error {
 }
 
 // convertOp: [N]T -> []T
-func cvtArrayToSlice(v, t rt.Value) error {
+func (c *Copier) cvtArrayToSlice(v, t rt.Value) error {
 	vType := v.Typ.(*reflect2.UnsafeArrayType)
 	tType := t.Typ.(*reflect2.UnsafeSliceType)
 	vElemType := vType.Elem()
 	tElemType := tType.Elem()
 	vLength := vType.Len()
 	tPtr := tType.UnsafeNew()
+	if vLength > 0 {
+		tPtr = c.makeSlice(tType, vLength)
+	}
 	for i := 0; i < vLength; i++ {
-		elemConverter := LoadConvertFunc(vElemType, tElemType)
-		tType.UnsafeGrow(tPtr, i+1)
+		if err := tick(v.St); err != nil {
+			return errAtIndex(err, i)
+		}
+		elemConverter := c.LoadConvertFunc(vElemType, tElemType)
 		tElemPtr := tType.UnsafeGetIndex(tPtr, i)
 		vElemPtr := vType.UnsafeGetIndex(v.Ptr, i)
 		err := elemConverter(rt.Value{
 			Ptr: vElemPtr,
 			Typ: vElemType,
+			St:  v.St,
 		}, rt.Value{
 			Ptr: tElemPtr,
 			Typ: tElemType,
 		})
 		if err != nil {
-			return err
+			return errAtIndex(err, i)
 		}
 	}
 	tType.UnsafeSet(t.Ptr, tPtr)
@@ -604,7 +689,7 @@ func cvtArrayToSlice(v, t rt.Value) error {
 }
 
 // convertOp: [N]T -> [N]T
-func cvtArray(v, t rt.Value) error {
+func (c *Copier) cvtArray(v, t rt.Value) error {
 	vType := v.Typ.(*reflect2.UnsafeArrayType)
 	tType := t.Typ.(*reflect2.UnsafeArrayType)
 	vElemType := vType.Elem()
@@ -612,25 +697,29 @@ func cvtArray(v, t rt.Value) error {
 	vLength := vType.Len()
 	tLength := tType.Len()
 	for i := 0; i < vLength && i < tLength; i++ {
-		elemConverter := LoadConvertFunc(vElemType, tElemType)
+		if err := tick(v.St); err != nil {
+			return errAtIndex(err, i)
+		}
+		elemConverter := c.LoadConvertFunc(vElemType, tElemType)
 		tElemPtr := tType.UnsafeGetIndex(t.Ptr, i)
 		vElemPtr := vType.UnsafeGetIndex(v.Ptr, i)
 		err := elemConverter(rt.Value{
 			Ptr: vElemPtr,
 			Typ: vElemType,
+			St:  v.St,
 		}, rt.Value{
 			Ptr: tElemPtr,
 			Typ: tElemType,
 		})
 		if err != nil {
-			return err
+			return errAtIndex(err, i)
 		}
 	}
 	return nil
 }
 
 // convertOp: T -> interface{}
-func cvtTToI(v rt.Value, t rt.Value) error {
+func (c *Copier) cvtTToI(v rt.Value, t rt.Value) error {
 	vKind := getKind(v.Typ)
 	tPObj := (*interface{})(t.Ptr)
 	var vObj interface{}
@@ -646,11 +735,13 @@ func cvtTToI(v rt.Value, t rt.Value) error {
 	case reflect.Complex64, reflect.Complex128:
 		vObj = v.Complex()
 	case reflect.String:
+		if err := enterLeaf(v); err != nil {
+			return err
+		}
 		vObj = v.String()
 	case reflect.Map, reflect.Array, reflect.Slice, reflect.Struct:
-		vObj = v.Typ.UnsafeNew()
-		vPtr := reflect2.PtrOf(vObj)
-		cvtFunc := LoadConvertFunc(v.Typ, v.Typ)
+		vPtr := c.newValue(v.Typ)
+		cvtFunc := c.LoadConvertFunc(v.Typ, v.Typ)
 		if cvtFunc == nil {
 			return nil
 		}
@@ -670,37 +761,40 @@ func cvtTToI(v rt.Value, t rt.Value) error {
 }
 
 // convertOp: interface{} -> T
-func cvtIToT(v rt.Value, t rt.Value) error {
-	vObj := v.Typ.UnsafeIndirect(v.Ptr)
-	v.Typ = reflect2.TypeOf(vObj)
-	if v.Typ.Kind() == reflect.Ptr {
-		v.Typ = v.Typ.(*reflect2.UnsafePtrType).Elem()
-	}
-	v.Ptr = reflect2.PtrOf(vObj)
-	cvtFunc := LoadConvertFunc(v.Typ, t.Typ)
+func (c *Copier) cvtIToT(v rt.Value, t rt.Value) error {
+	v = unpackEFace(v.Typ.UnsafeIndirect(v.Ptr), v.St)
+	cvtFunc := c.LoadConvertFunc(v.Typ, t.Typ)
 	return cvtFunc(v, t)
 }
 
 // convertOp: interface{} -> interface{}
-func cvtIToI(v rt.Value, t rt.Value) error {
-	vObj := v.Typ.UnsafeIndirect(v.Ptr)
-	v.Typ = reflect2.TypeOf(vObj)
-	if v.Typ.Kind() == reflect.Ptr {
-		v.Typ = v.Typ.(*reflect2.UnsafePtrType).Elem()
+func (c *Copier) cvtIToI(v rt.Value, t rt.Value) error {
+	v = unpackEFace(v.Typ.UnsafeIndirect(v.Ptr), v.St)
+	if _, ok := t.Typ.(*reflect2.UnsafeEFaceType); ok && v.Typ.Kind() == reflect.Ptr && c.interfaceMode != InterfaceJSON {
+		// 接口中保存的指针仍以指针形式存入目标接口
+		return c.cvtTToIPreserve(v, t)
 	}
-	v.Ptr = reflect2.PtrOf(vObj)
-	cvtFunc := LoadConvertFunc(v.Typ, t.Typ)
+	cvtFunc := c.LoadConvertFunc(v.Typ, t.Typ)
 	return cvtFunc(v, t)
 }
 
-func cvtTToPtr(v rt.Value, t rt.Value) error {
-	if v.Typ.Kind() == reflect.Ptr && *((*unsafe.Pointer)(v.Ptr)) == nil {
-		*((*unsafe.Pointer)(t.Ptr)) = nil
-		return nil
+func (c *Copier) cvtTToPtr(v rt.Value, t rt.Value) error {
+	if v.Typ.Kind() == reflect.Ptr {
+		vPtr := *((*unsafe.Pointer)(v.Ptr))
+		if vPtr == nil {
+			*((*unsafe.Pointer)(t.Ptr)) = nil
+			return nil
+		}
+		// 先解引用源指针，避免 *T -> interface{} 等转换把指针本身当作值
+		v = rt.Value{
+			Ptr: vPtr,
+			Typ: v.Typ.(*reflect2.UnsafePtrType).Elem(),
+			St:  v.St,
+		}
 	}
 	t.Typ = t.Typ.(*reflect2.UnsafePtrType).Elem()
-	cvtFunc := LoadConvertFunc(v.Typ, t.Typ)
-	newPtr := t.Typ.UnsafeNew()
+	cvtFunc := c.LoadConvertFunc(v.Typ, t.Typ)
+	newPtr := c.newValue(t.Typ)
 	err := cvtFunc(v, rt.Value{
 		Ptr: newPtr,
 		Typ: t.Typ,
@@ -712,9 +806,9 @@ func cvtTToPtr(v rt.Value, t rt.Value) error {
 	return nil
 }
 
-func cvtPtrToT(v rt.Value, t rt.Value) error {
+func (c *Copier) cvtPtrToT(v rt.Value, t rt.Value) error {
 	v.Typ = v.Typ.(*reflect2.UnsafePtrType).Elem()
-	cvtFunc := LoadConvertFunc(v.Typ, t.Typ)
+	cvtFunc := c.LoadConvertFunc(v.Typ, t.Typ)
 	if cvtFunc == nil {
 		return nil
 	}
@@ -730,6 +824,7 @@ func cvtPtrToT(v rt.Value, t rt.Value) error {
 		err := cvtFunc(rt.Value{
 			Ptr: vPtr,
 			Typ: v.Typ,
+			St:  v.St,
 		}, t)
 		if err != nil {
 			return err
@@ -738,45 +833,96 @@ func cvtPtrToT(v rt.Value, t rt.Value) error {
 	return nil
 }
 
-// convertOp: struct -> struct
-func cvtStructToStruct(v rt.Value, t rt.Value) error {
-	vInfo := loadStructFieldsInfo(v.Typ)
-	tInfo := loadStructFieldsInfo(t.Typ)
-	tFieldMap := tInfo.FieldMap
-	vPtr := v.Ptr
-	tPtr := t.Ptr
-	for i := 0; i < len(vInfo.Fields); i++ {
-		f := vInfo.Fields[i]
+// fieldPlan 结构体字段之间的一次转换，字段匹配与转换函数在构建计划时确定
+type fieldPlan struct {
+	name string
+	// v、t 为源字段与目标字段的访问路径，map -> struct 中 v 为 nil
+	v       *fieldPath
+	t       *fieldPath
+	vType   reflect2.Type
+	tType   reflect2.Type
+	cvtFunc ConvertFunc
+	// required 源值缺失或为 nil 时报错；setDefault 源值缺失或为 nil 时写入标签中的默认值
+	required   bool
+	setDefault func(t unsafe.Pointer) error
+	// slot 为 map -> struct 中需要记录是否出现的字段序号，其余为 -1
+	slot int
+	// root 为目标顶层字段的 Go 名称；custom 表示值由转换函数、方法等产生，不能再按字段掩码深入
+	root   string
+	custom bool
+}
 
-		tf, ok := tFieldMap[f.Name]
-		if !ok {
-			continue
+// structToStructOp 返回 struct -> struct 的转换函数，字段按名称匹配并预先加载各字段的转换函数；
+// scope 不为 nil 时只拷贝字段掩码选中的字段
+func (c *Copier) structToStructOp(v, t reflect2.Type, scope *maskScope) func(v, t rt.Value) error {
+	fields, defaults, err := c.structFieldPlans(v, t)
+	if err == nil && scope != nil {
+		fields, defaults, err = c.maskFieldPlans(scope, t, fields, defaults)
+	}
+	if err != nil {
+		// 缺少必填字段、默认值或转换函数无效等错误与取值无关，每次拷贝都失败
+		return func(v, t rt.Value) error {
+			return err
 		}
-		fType := f.Field.Type()
-		tfType := tf.Field.Type()
-		// 直接使用指针 + 偏移，避免去将指针转换为对象
-		childVPtr := pointerOffset(vPtr, f.Field.Offset())
-		childTPtr := pointerOffset(tPtr, tf.Field.Offset())
-		cvtFunc := LoadConvertFunc(fType, tfType)
-		if cvtFunc == nil {
-			continue
+	}
+	return func(v, t rt.Value) error {
+		var missing []string
+		for i := range fields {
+			f := &fields[i]
+			// 直接使用指针 + 偏移，避免去将指针转换为对象
+			vPtr := f.v.read(v.Ptr)
+			if vPtr == nil {
+				// 源字段路径上的指针为 nil，视同源值缺失
+				if f.required {
+					missing = append(missing, f.name)
+				}
+				if f.setDefault != nil {
+					if err := f.setDefault(f.t.write(t.Ptr, c)); err != nil {
+						return err
+					}
+				} else if tPtr := f.t.read(t.Ptr); tPtr != nil {
+					f.tType.UnsafeSet(tPtr, f.tType.UnsafeNew())
+				}
+				continue
+			}
+			tPtr := f.t.write(t.Ptr, c)
+			if (f.required || f.setDefault != nil) && f.vType.UnsafeIsNil(vPtr) {
+				if f.required {
+					missing = append(missing, f.name)
+				}
+				if f.setDefault != nil {
+					if err := f.setDefault(tPtr); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			err := f.cvtFunc(rt.Value{
+				Ptr: vPtr,
+				Typ: f.vType,
+				St:  v.St,
+			}, rt.Value{
+				Ptr: tPtr,
+				Typ: f.tType,
+			})
+			if err != nil {
+				return errAtField(err, f.v.name)
+			}
 		}
-		err := cvtFunc(rt.Value{
-			Ptr: childVPtr,
-			Typ: fType,
-		}, rt.Value{
-			Ptr: childTPtr,
-			Typ: tfType,
-		})
-		if err != nil {
-			return err
+		for i := range defaults {
+			if err := defaults[i].setDefault(defaults[i].t.write(t.Ptr, c)); err != nil {
+				return err
+			}
 		}
+		if len(missing) > 0 {
+			return &FieldsError{Type: t.Typ.Type1(), Missing: missing}
+		}
+		return nil
 	}
-	return nil
 }
 
 // convertOp: map -> map
-func cvtMapToMap(v rt.Value, t rt.Value) error {
+func (c *Copier) cvtMapToMap(v rt.Value, t rt.Value) error {
 	vType := v.Typ.(*reflect2.UnsafeMapType)
 	tType := t.Typ.(*reflect2.UnsafeMapType)
 	if tType.UnsafeIsNil(t.Ptr) {
@@ -791,34 +937,40 @@ func cvtMapToMap(v rt.Value, t rt.Value) error {
 	vElemType := vType.Elem()
 	tElemType := tType.Elem()
 	iter := vType.UnsafeIterate(v.Ptr)
-	keyConverter := LoadConvertFunc(vKType, tKType)
+	keyConverter := c.LoadConvertFunc(vKType, tKType)
 	for iter.HasNext() {
+		if err := tick(v.St); err != nil {
+			return err
+		}
 		vKey, vElem := iter.UnsafeNext()
-		elemConverter := LoadConvertFunc(vElemType, tElemType)
+		elemConverter := c.LoadConvertFunc(vElemType, tElemType)
 		if keyConverter == nil || elemConverter == nil {
 			continue
 		}
 		tKey := tKType.UnsafeNew()
 		tElem := tElemType.UnsafeNew()
-		err := keyConverter(rt.Value{
+		key := rt.Value{
 			Ptr: vKey,
 			Typ: vKType,
-		}, rt.Value{
+			St:  v.St,
+		}
+		err := keyConverter(key, rt.Value{
 			Ptr: tKey,
 			Typ: tKType,
 		})
 		if err != nil {
-			return err
+			return errAtKey(err, key)
 		}
 		err = elemConverter(rt.Value{
 			Ptr: vElem,
 			Typ: vElemType,
+			St:  v.St,
 		}, rt.Value{
 			Ptr: tElem,
 			Typ: tElemType,
 		})
 		if err != nil {
-			return err
+			return errAtKey(err, key)
 		}
 		if tKType.UnsafeIsNil(tKey) {
 			continue
@@ -829,7 +981,7 @@ func cvtMapToMap(v rt.Value, t rt.Value) error {
 }
 
 // convertOp: struct -> map
-func cvtStructToMap(v rt.Value, t rt.Value) error {
+func (c *Copier) cvtStructToMap(v rt.Value, t rt.Value) error {
 	tType := t.Typ.(*reflect2.UnsafeMapType)
 	if tType.UnsafeIsNil(t.Ptr) {
 		tType.UnsafeSet(t.Ptr, tType.UnsafeMakeMap(0))
@@ -839,73 +991,245 @@ func cvtStructToMap(v rt.Value, t rt.Value) error {
 		return nil
 	}
 	tElemType := tType.Elem()
-	vInfo := loadStructFieldsInfo(v.Typ)
+	vInfo := c.loadStructFieldsInfo(v.Typ)
 	for i := 0; i < len(vInfo.Fields); i++ {
 		f := vInfo.Fields[i].Field
 
 		fType := f.Type()
 		// 直接使用指针 + 偏移，避免去将指针转换为对象
-		childVPtr := pointerOffset(v.Ptr, f.Offset())
+		childVPtr := vInfo.Fields[i].path.read(v.Ptr)
+		if childVPtr == nil {
+			// 嵌入的指针为 nil，没有可拷贝的字段
+			continue
+		}
 		name := f.Name()
 		tElem := tElemType.UnsafeNew()
-		elemConverter := LoadConvertFunc(fType, tElemType)
+		elemConverter := c.LoadConvertFunc(fType, tElemType)
+		if c.redacts(vInfo.Fields[i].sensitive) {
+			if c.redaction == RedactDrop {
+				continue
+			}
+			elemConverter = c.redactOp(fType, tElemType)
+		}
 		if elemConverter == nil {
 			continue
 		}
 		err := elemConverter(rt.Value{
 			Ptr: childVPtr,
 			Typ: fType,
+			St:  v.St,
 		}, rt.Value{
 			Ptr: tElem,
 			Typ: tElemType,
 		})
 		if err != nil {
-			return err
+			return errAtField(err, vInfo.Fields[i].Name)
 		}
 		tType.UnsafeSetIndex(t.Ptr, unsafe.Pointer(&name), tElem)
 	}
 	return nil
 }
 
-// convertOp: map -> struct
-func cvtMapToStruct(v rt.Value, t rt.Value) error {
-	vType := v.Typ.(*reflect2.UnsafeMapType)
-	if vType.UnsafeIsNil(v.Ptr) {
-		return nil
+// structFieldPlans 构建 struct -> struct 的字段计划，defaults 为没有对应源字段、每次都写入默认值的目标字段
+func (c *Copier) structFieldPlans(v, t reflect2.Type) (fields, defaults []fieldPlan, err error) {
+	matches, unmatchedV, unmatchedT, err := c.matchStructFields(v, t)
+	if err != nil {
+		return nil, nil, err
 	}
-	vKType := vType.Key()
-	if vKType.Kind() != reflect.String {
-		return nil
+	if err := c.structFieldsError(t, unmatchedV, unmatchedT); err != nil {
+		return nil, nil, err
+	}
+	for _, tf := range unmatchedT {
+		setDefault, err := c.defaultOp(t, tf)
+		if err != nil {
+			return nil, nil, err
+		}
+		if setDefault != nil {
+			defaults = append(defaults, fieldPlan{name: tf.Name, t: tf.path, setDefault: setDefault, root: tf.Field.Name()})
+		}
+	}
+	fields = make([]fieldPlan, 0, len(matches))
+	for _, m := range matches {
+		vEdge, tEdge := m.edge()
+		c.visitEdge(m.v.name, m.t.name, vEdge, tEdge)
+		plan := fieldPlan{
+			name:  m.t.name,
+			v:     m.v,
+			t:     m.t,
+			vType: m.v.typ,
+			tType: m.t.typ,
+			root:  strings.SplitN(m.t.name, ".", 2)[0],
+			custom: m.convert.IsValid() || m.getter.IsValid() || m.setter.IsValid() ||
+				(m.tBinding != nil && len(m.tBinding.transforms) > 0),
+		}
+		if tf := m.tBinding; tf != nil {
+			plan.name = tf.Name
+			setDefault, err := c.defaultOp(t, tf)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !m.convert.IsValid() && !m.getter.IsValid() {
+				plan.cvtFunc, err = c.transformOp(t, tf, plan.vType)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+			// 源字段可为 nil 或路径上有指针时才需要在拷贝时检查
+			if isNilable(plan.vType) || len(m.v.hops) > 0 {
+				plan.required = c.isRequired(tf)
+				plan.setDefault = setDefault
+			}
+		}
+		switch {
+		case m.convert.IsValid():
+			plan.cvtFunc, err = c.mappingConvertOp(t, m)
+			if err != nil {
+				return nil, nil, err
+			}
+		case m.getter.IsValid():
+			plan.cvtFunc = c.getterOp(m)
+		case m.setter.IsValid():
+			plan.cvtFunc = c.setterOp(m)
+		}
+		if plan.cvtFunc == nil {
+			plan.cvtFunc = c.LoadConvertFunc(plan.vType, plan.tType)
+		}
+		if c.redacts(m.v.sensitive || m.t.sensitive) {
+			// 敏感字段不传给赋值方法
+			if m.setter.IsValid() {
+				continue
+			}
+			plan.cvtFunc, plan.custom = c.redactOp(plan.vType, plan.tType), true
+		}
+		fields = append(fields, plan)
+	}
+	return fields, defaults, nil
+}
+
+// mapToStructOp 返回 map -> struct 的转换函数，按键名查找预先构建的字段计划；
+// scope 不为 nil 时忽略字段掩码未选中的键
+func (c *Copier) mapToStructOp(v, t reflect2.Type, scope *maskScope) func(v, t rt.Value) error {
+	vType := v.(*reflect2.UnsafeMapType)
+	if vType.Key().Kind() != reflect.String {
+		return func(v, t rt.Value) error {
+			return nil
+		}
 	}
-	tInfo := loadStructFieldsInfo(t.Typ)
-	tFieldMap := tInfo.FieldMap
 	vElemType := vType.Elem()
-	iter := vType.UnsafeIterate(v.Ptr)
-	for iter.HasNext() {
-		vKey, vElem := iter.UnsafeNext()
-		key := *(*string)(vKey)
-		tf, ok := tFieldMap[key]
+	tInfo := c.loadStructFieldsInfo(t)
+	fields := make(map[string]*fieldPlan, len(tInfo.Fields))
+	// tracked 为必填或带默认值的字段，拷贝时记录其是否出现且不为 nil
+	var tracked []*fieldPlan
+	for _, tf := range tInfo.Fields {
+		next, ok := scope.pick(tf.Field.Name(), tf.Name)
 		if !ok {
+			// 未选中的键不拷贝，也不视为未知字段
+			fields[tf.Name] = &fieldPlan{name: tf.Name, slot: -1}
 			continue
 		}
-		tfType := tf.Field.Type()
-		cvtFunc := LoadConvertFunc(vElemType, tfType)
-		if cvtFunc == nil {
-			continue
+		c.visitEdge(tf.Name, tf.Field.Name(), vElemType, tf.Field.Type())
+		setDefault, err := c.defaultOp(t, tf)
+		if err != nil {
+			return func(v, t rt.Value) error {
+				return err
+			}
 		}
-		childTPtr := pointerOffset(t.Ptr, tf.Field.Offset())
-		err := cvtFunc(rt.Value{
-			Ptr: vElem,
-			Typ: vElemType,
-		}, rt.Value{
-			Ptr: childTPtr,
-			Typ: tfType,
-		})
+		cvtFunc, err := c.maskedFunc(scope, next, vElemType, tf.Field.Type())
 		if err != nil {
-			return err
+			return func(v, t rt.Value) error {
+				return err
+			}
+		}
+		if c.redacts(tf.sensitive) {
+			cvtFunc = c.redactOp(vElemType, tf.Field.Type())
+		}
+		f := &fieldPlan{
+			name:       tf.Name,
+			t:          tf.path,
+			vType:      vElemType,
+			tType:      tf.Field.Type(),
+			cvtFunc:    cvtFunc,
+			required:   c.isRequired(tf),
+			setDefault: setDefault,
+			slot:       -1,
 		}
+		if f.required || f.setDefault != nil {
+			f.slot = len(tracked)
+			tracked = append(tracked, f)
+		}
+		fields[tf.Name] = f
+	}
+	if d, ok := loadDiscriminator(t.Type1()); ok && fields[d.field] == nil {
+		// 已登记类型的判别字段用于选择具体类型，不视为未知字段
+		fields[d.field] = &fieldPlan{name: d.field, slot: -1}
+	}
+	nilable := isNilable(vElemType)
+	return func(v, t rt.Value) error {
+		var (
+			found   []bool
+			unknown []string
+		)
+		if len(tracked) > 0 {
+			found = make([]bool, len(tracked))
+		}
+		iter := vType.UnsafeIterate(v.Ptr)
+		for iter.HasNext() {
+			if err := tick(v.St); err != nil {
+				return err
+			}
+			vKey, vElem := iter.UnsafeNext()
+			key := *(*string)(vKey)
+			f, ok := fields[key]
+			if !ok {
+				if c.disallowUnknownFields {
+					unknown = append(unknown, key)
+				}
+				continue
+			}
+			if f.cvtFunc == nil {
+				continue
+			}
+			if f.slot >= 0 {
+				if !(nilable && vElemType.UnsafeIsNil(vElem)) {
+					found[f.slot] = true
+				} else if f.setDefault != nil {
+					// nil 值由下方统一写入默认值
+					continue
+				}
+			}
+			err := f.cvtFunc(rt.Value{
+				Ptr: vElem,
+				Typ: vElemType,
+				St:  v.St,
+			}, rt.Value{
+				Ptr: f.t.write(t.Ptr, c),
+				Typ: f.tType,
+			})
+			if err != nil {
+				return errAtField(err, key)
+			}
+		}
+		var missing []string
+		for i, ok := range found {
+			if ok {
+				continue
+			}
+			f := tracked[i]
+			if f.required {
+				missing = append(missing, f.name)
+			}
+			if f.setDefault != nil {
+				if err := f.setDefault(f.t.write(t.Ptr, c)); err != nil {
+					return err
+				}
+			}
+		}
+		if len(missing) > 0 || len(unknown) > 0 {
+			sort.Strings(unknown)
+			return &FieldsError{Type: t.Typ.Type1(), Missing: missing, Unknown: unknown}
+		}
+		return nil
 	}
-	return nil
 }
 
 func pointerOffset(p unsafe.Pointer, offset uintptr) (pOut unsafe.Pointer) {
@@ -0,0 +1,57 @@
+package go_deep_copy
+
+// Copier holds copy options together with the convert funcs compiled for them.
+// A Copier is safe for concurrent use; create it once and reuse it.
+type Copier struct {
+	noCopyPolicy  NoCopyPolicy
+	interfaceMode InterfaceMode
+	// 拷贝到结构体时对缺失字段与多余字段的校验
+	disallowUnknownFields bool
+	requireAllFields      bool
+	// 按名称登记的字段转换，仅在 NewCopier 中写入
+	transforms map[string][]*transform
+	// 按 [from, to] 结构体类型登记的字段映射，仅在 NewCopier 中写入
+	mappings map[[2]uintptr]*structMapping
+	// 是否通过取值方法与赋值方法映射字段
+	methods bool
+	// 标记为 sensitive 的字段的脱敏策略
+	redaction RedactionPolicy
+	// 拷贝时检查的深度、元素个数与字符串长度限制
+	limits Limits
+	// 不为 nil 时并行拷贝较长的切片
+	parallel *parallelism
+	// 不为 nil 时新的指针目标与切片底层数组由其分配
+	allocator Allocator
+
+	// 按 [from, to] 类型对缓存转换函数及结构体描述，并按路径集合缓存字段掩码，不同配置的 Copier 互不共享
+	cache *planCache
+	// 不为 nil 时为 planEdges 使用的副本，只记录编译中的子转换
+	visitor *planVisitor
+}
+
+// Option configures a Copier.
+type Option func(c *Copier)
+
+var (
+	defaultCopier = NewCopier()
+	cloneCopier   = NewCopier(WithInterfaceMode(InterfacePreserve))
+)
+
+// NewCopier creates a Copier with the given options.
+func NewCopier(opts ...Option) *Copier {
+	c := &Copier{
+		cache: newPlanCache(),
+	}
+	for _, opt := range builtinTransforms {
+		opt(c)
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// DeepCopy deep copy things with the options of c
+func (c *Copier) DeepCopy(fromValue interface{}, toValue interface{}) (err error) {
+	return c.deepCopy(fromValue, toValue)
+}
@@ -0,0 +1,272 @@
+package go_deep_copy
+
+import (
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// CSVEncoder writes structs as CSV rows. Each exported field is a column named
+// by its tag name or Go name; nested struct fields are flattened into dotted
+// columns such as "addr.city". Cells are formatted with the same conversions
+// as copying into a string, floats with strconv.FormatFloat, and values
+// implementing encoding.TextMarshaler with MarshalText.
+type CSVEncoder struct {
+	c      *Copier
+	w      *csv.Writer
+	typ    reflect2.Type
+	cols   []csvColumn
+	record []string
+}
+
+// CSVDecoder reads CSV rows into structs, matching the header row to the
+// columns a CSVEncoder would write. Cells are parsed with the same conversions
+// as copying from a string, so the tag options required and default apply, and
+// WithDisallowUnknownFields rejects columns the struct does not have. Empty
+// cells leave the field zero, and pointers on its path nil.
+type CSVDecoder struct {
+	c    *Copier
+	r    *csv.Reader
+	typ  reflect2.Type
+	cols []*csvColumn
+	// absent 为表头中没有、每行都按默认值写入的字段
+	absent []csvColumn
+	// err 为读取表头时的错误，之后的每次 Decode 都返回它
+	err error
+}
+
+// csvColumn 为一列与结构体叶子字段之间的转换
+type csvColumn struct {
+	field      *flatField
+	format     func(ptr unsafe.Pointer) (string, error)
+	parse      func(s string, ptr unsafe.Pointer) error
+	required   bool
+	setDefault func(t unsafe.Pointer) error
+}
+
+// NewCSVEncoder returns a CSVEncoder writing to w with the default Copier.
+func NewCSVEncoder(w *csv.Writer) *CSVEncoder {
+	return defaultCopier.NewCSVEncoder(w)
+}
+
+// NewCSVEncoder returns a CSVEncoder writing to w with the options of c.
+// Sensitive fields follow the redaction policy of c; RedactDrop omits them.
+func (c *Copier) NewCSVEncoder(w *csv.Writer) *CSVEncoder {
+	return &CSVEncoder{c: c, w: w}
+}
+
+// NewCSVDecoder returns a CSVDecoder reading from r with the default Copier.
+func NewCSVDecoder(r *csv.Reader) *CSVDecoder {
+	return defaultCopier.NewCSVDecoder(r)
+}
+
+// NewCSVDecoder returns a CSVDecoder reading from r with the options of c.
+func (c *Copier) NewCSVDecoder(r *csv.Reader) *CSVDecoder {
+	return &CSVDecoder{c: c, r: r}
+}
+
+// WriteHeader writes the header row for the struct type of v, for exports that
+// may have no rows. Encode writes it otherwise.
+func (e *CSVEncoder) WriteHeader(v interface{}) error {
+	if e.typ != nil {
+		return nil
+	}
+	typ, _, err := csvStruct(v)
+	if err != nil {
+		return err
+	}
+	e.cols = nil
+	for _, f := range e.c.flatFields(typ) {
+		col := csvColumn{field: f}
+		if e.c.redacts(f.path.sensitive) {
+			if e.c.redaction == RedactDrop {
+				continue
+			}
+			redact := e.c.redactOp(f.path.typ, stringType)
+			col.format = func(ptr unsafe.Pointer) (string, error) {
+				var s string
+				err := redact(rt.Value{Typ: f.path.typ, Ptr: ptr}, rt.Value{Typ: stringType, Ptr: unsafe.Pointer(&s)})
+				return s, err
+			}
+		} else if col.format, err = e.c.formatOp(f.path.typ); err != nil {
+			return fmt.Errorf("csv column %s: %w", f.name, err)
+		}
+		e.cols = append(e.cols, col)
+	}
+	e.typ = typ
+	e.record = make([]string, len(e.cols))
+	for i := range e.cols {
+		e.record[i] = e.cols[i].field.name
+	}
+	return e.w.Write(e.record)
+}
+
+// Encode writes v, a struct or a pointer to one, as a CSV row, writing the
+// header row first. All rows must have the same type. Rows are buffered by the
+// csv.Writer; call Flush when done.
+func (e *CSVEncoder) Encode(v interface{}) error {
+	if err := e.WriteHeader(v); err != nil {
+		return err
+	}
+	typ, ptr, err := csvStruct(v)
+	if err != nil {
+		return err
+	}
+	if typ.RType() != e.typ.RType() {
+		return fmt.Errorf("csv rows must be %s, got %s", e.typ.Type1(), typ.Type1())
+	}
+	for i := range e.cols {
+		col := &e.cols[i]
+		e.record[i] = ""
+		// 路径上的指针为 nil 时为空单元格
+		if fieldPtr := col.field.path.read(ptr); fieldPtr != nil {
+			if e.record[i], err = col.format(fieldPtr); err != nil {
+				return fmt.Errorf("csv column %s: %w", col.field.name, err)
+			}
+		}
+	}
+	return e.w.Write(e.record)
+}
+
+// Flush writes buffered rows and returns any write error.
+func (e *CSVEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// Decode reads the next row into v, a non-nil pointer to a struct, reading the
+// header row first. Fields are reset before each row. It returns io.EOF when
+// there are no more rows.
+func (d *CSVDecoder) Decode(v interface{}) error {
+	typ, ptr, err := csvStruct(v)
+	if err != nil {
+		return err
+	}
+	if reflect.ValueOf(v).Kind() != reflect.Ptr {
+		return ErrInvalidCopyDestination
+	}
+	if d.typ == nil {
+		d.typ = typ
+		d.err = d.readHeader(typ)
+	} else if typ.RType() != d.typ.RType() {
+		return fmt.Errorf("csv rows must be %s, got %s", d.typ.Type1(), typ.Type1())
+	}
+	if d.err != nil {
+		return d.err
+	}
+	record, err := d.r.Read()
+	if err != nil {
+		return err
+	}
+	line, _ := d.r.FieldPos(0)
+	typ.UnsafeSet(ptr, typ.UnsafeNew())
+	var missing []string
+	for i, cell := range record {
+		if i >= len(d.cols) || d.cols[i] == nil {
+			continue
+		}
+		col := d.cols[i]
+		if cell == "" {
+			// 各字段已重置为零值，空单元格不必解析，也不会创建路径上的指针
+			if col.required {
+				missing = append(missing, col.field.name)
+			}
+			if col.setDefault != nil {
+				if err := col.setDefault(col.field.path.write(ptr, d.c)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := col.parse(cell, col.field.path.write(ptr, d.c)); err != nil {
+			return fmt.Errorf("csv line %d, column %s: %w", line, col.field.name, err)
+		}
+	}
+	for i := range d.absent {
+		if err := d.absent[i].setDefault(d.absent[i].field.path.write(ptr, d.c)); err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("csv line %d: %w", line, &FieldsError{Type: typ.Type1(), Missing: missing})
+	}
+	return nil
+}
+
+// readHeader 读取表头并为各列构建转换函数
+func (d *CSVDecoder) readHeader(typ reflect2.Type) error {
+	header, err := d.r.Read()
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	d.cols = make([]*csvColumn, len(header))
+	var missing []string
+	for _, f := range d.c.flatFields(typ) {
+		setDefault, err := d.c.defaultOp(f.owner, f.binding)
+		if err != nil {
+			return err
+		}
+		col := &csvColumn{field: f, required: d.c.isRequired(f.binding), setDefault: setDefault}
+		i, ok := columns[f.name]
+		if !ok {
+			if col.required {
+				missing = append(missing, f.name)
+			}
+			if setDefault != nil {
+				d.absent = append(d.absent, *col)
+			}
+			continue
+		}
+		delete(columns, f.name)
+		if d.c.redacts(f.path.sensitive) {
+			redact := d.c.redactOp(stringType, f.path.typ)
+			col.parse = func(s string, ptr unsafe.Pointer) error {
+				return redact(rt.Value{Typ: stringType, Ptr: unsafe.Pointer(&s)}, rt.Value{Typ: f.path.typ, Ptr: ptr})
+			}
+		} else if col.parse, err = d.c.parseOp(f.path.typ); err != nil {
+			return fmt.Errorf("csv column %s: %w", f.name, err)
+		}
+		d.cols[i] = col
+	}
+	var unknown []string
+	if d.c.disallowUnknownFields {
+		for _, name := range header {
+			if _, ok := columns[name]; ok {
+				unknown = append(unknown, name)
+			}
+		}
+	}
+	if len(missing) > 0 || len(unknown) > 0 {
+		return &FieldsError{Type: typ.Type1(), Missing: missing, Unknown: unknown}
+	}
+	return nil
+}
+
+// csvStruct 返回 v 中结构体的类型与地址，v 可以是结构体或指向结构体的非 nil 指针
+func csvStruct(v interface{}) (reflect2.Type, unsafe.Pointer, error) {
+	value := reflect.ValueOf(v)
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, nil, ErrInvalidCopyDestination
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("csv rows must be structs, got %T: %w", v, ErrNotSupported)
+	}
+	if !value.CanAddr() {
+		// 按值传入的结构体先复制到可寻址的位置
+		ptr := reflect.New(value.Type())
+		ptr.Elem().Set(value)
+		value = ptr.Elem()
+	}
+	return reflect2.Type2(value.Type()), unsafe.Pointer(value.UnsafeAddr()), nil
+}
@@ -1,6 +1,7 @@
 package go_deep_copy
 
 import (
+	"context"
 	"github.com/LiZhiqiang0/go_deep_copy/rt"
 	"github.com/LiZhiqiang0/reflect2"
 	"reflect"
@@ -9,22 +10,47 @@ import (
 
 // DeepCopy deep copy things
 func DeepCopy(fromValue interface{}, toValue interface{}) (err error) {
-	return deepCopy(fromValue, toValue)
+	return defaultCopier.deepCopy(fromValue, toValue)
 }
 
-func deepCopy(fromValue interface{}, toValue interface{}) (err error) {
+// Clone returns a deep copy of value. Unlike DeepCopy, values held in interfaces
+// keep their exact dynamic types (see InterfacePreserve).
+func Clone[T any](value T) (T, error) {
+	var out T
+	typ := reflect2.TypeOf(&out).(*reflect2.UnsafePtrType).Elem()
+	cvtFunc := cloneCopier.LoadConvertFunc(typ, typ)
+	err := cvtFunc(rt.Value{
+		Typ: typ,
+		Ptr: unsafe.Pointer(&value),
+	}, rt.Value{
+		Typ: typ,
+		Ptr: unsafe.Pointer(&out),
+	})
+	return out, err
+}
+
+func (c *Copier) deepCopy(fromValue interface{}, toValue interface{}) (err error) {
+	v, t, err := c.copyValues(context.Background(), fromValue, toValue)
+	if err != nil {
+		return err
+	}
+	return c.LoadConvertFunc(v.Typ, t.Typ)(v, t)
+}
+
+// copyValues 解引用 fromValue 与 toValue，返回带有单次拷贝状态的源值与目标值，ctx 取消时中止拷贝
+func (c *Copier) copyValues(ctx context.Context, fromValue interface{}, toValue interface{}) (v, t rt.Value, err error) {
 	var (
 		from = indirect(reflect.ValueOf(fromValue))
 		to   = indirect(reflect.ValueOf(toValue))
 	)
 
 	if !to.CanAddr() {
-		return ErrInvalidCopyDestination
+		return v, t, ErrInvalidCopyDestination
 	}
 
 	// Return is from value is invalid
 	if !from.IsValid() {
-		return ErrInvalidCopyFrom
+		return v, t, ErrInvalidCopyFrom
 	}
 
 	var fromPtr unsafe.Pointer
@@ -34,16 +60,16 @@ func deepCopy(fromValue interface{}, toValue interface{}) (err error) {
 		fromPtr = reflect2.PtrOf(fromValue)
 	}
 	toPtr := unsafe.Pointer(to.UnsafeAddr())
-	fromType2 := reflect2.Type2(from.Type())
-	toType2 := reflect2.Type2(to.Type())
-	cvtFunc := LoadConvertFunc(fromType2, toType2)
-	return cvtFunc(rt.Value{
-		Typ: fromType2,
+	v = rt.Value{
+		Typ: reflect2.Type2(from.Type()),
 		Ptr: fromPtr,
-	}, rt.Value{
-		Typ: toType2,
+		St:  c.newState(ctx),
+	}
+	t = rt.Value{
+		Typ: reflect2.Type2(to.Type()),
 		Ptr: toPtr,
-	})
+	}
+	return v, t, nil
 }
 
 func indirect(reflectValue reflect.Value) reflect.Value {
@@ -0,0 +1,122 @@
+package go_deep_copy_test
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+// countingAllocator 在堆上分配并记录调用次数
+type countingAllocator struct {
+	calls int64
+}
+
+func (a *countingAllocator) Alloc(typ reflect.Type, n int) unsafe.Pointer {
+	atomic.AddInt64(&a.calls, 1)
+	return unsafe.Pointer(reflect.MakeSlice(reflect.SliceOf(typ), n, n).Pointer())
+}
+
+// TestAllocator 测试指针目标与切片底层数组通过 Allocator 分配
+func TestAllocator(t *testing.T) {
+	alloc := &countingAllocator{}
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithAllocator(alloc))
+
+	source := newMaskOrder()
+	var target Order
+	if err := copier.DeepCopy(&source, &target); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if !reflect.DeepEqual(target, source) {
+		t.Errorf("got %+v, want %+v", target, source)
+	}
+	// Customer 与 Items 各一次
+	if alloc.calls != 2 {
+		t.Errorf("expected 2 allocations, got %d", alloc.calls)
+	}
+
+	// 存入接口的值与点号路径上创建的中间指针同样通过 Allocator 分配
+	alloc.calls = 0
+	boxed := struct{ V interface{} }{V: OrderItem{SKU: "a", Count: 1}}
+	var boxedTarget struct{ V interface{} }
+	if err := copier.DeepCopy(&boxed, &boxedTarget); err != nil || !reflect.DeepEqual(boxedTarget, boxed) {
+		t.Errorf("boxed copy mismatch: %v, %+v", err, boxedTarget)
+	}
+	type customerName struct {
+		Name string `go_deep_copy:"Customer.Name"`
+	}
+	var order Order
+	if err := copier.DeepCopy(customerName{Name: "Bob"}, &order); err != nil || order.Customer == nil || order.Customer.Name != "Bob" {
+		t.Errorf("dotted copy mismatch: %v, %+v", err, order)
+	}
+	if alloc.calls != 2 {
+		t.Errorf("expected 2 allocations, got %d", alloc.calls)
+	}
+}
+
+// TestSlabAllocator 测试从 slab 中分配的拷贝在 GC 后仍然有效且互不影响
+func TestSlabAllocator(t *testing.T) {
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithAllocator(go_deep_copy.NewSlabAllocator(64)))
+
+	source := make([]Order, 40)
+	for i := range source {
+		source[i] = newMaskOrder()
+		source[i].ID = i
+	}
+	var target []Order
+	if err := copier.DeepCopy(&source, &target); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	runtime.GC()
+	if !reflect.DeepEqual(target, source) {
+		t.Fatal("slab copy mismatch")
+	}
+	if target[0].Customer == source[0].Customer || target[0].Customer == target[1].Customer {
+		t.Error("pointer targets should not be shared")
+	}
+
+	// 切片的容量等于长度，append 不会覆盖 slab 中相邻的值
+	target[0].Items = append(target[0].Items, OrderItem{SKU: "new"})
+	if !reflect.DeepEqual(target[1].Items, source[1].Items) {
+		t.Errorf("append overwrote a neighbour: %+v", target[1].Items)
+	}
+
+	// 超过 slab 大小的切片单独分配
+	long := newItems(100)
+	var longTarget []OrderItem
+	if err := copier.DeepCopy(&long, &longTarget); err != nil || !reflect.DeepEqual(longTarget, long) {
+		t.Errorf("long slice mismatch: %v", err)
+	}
+}
+
+// TestSlabAllocatorConcurrent 测试并发拷贝共用同一个 slab 分配器
+func TestSlabAllocatorConcurrent(t *testing.T) {
+	copier := go_deep_copy.NewCopier(
+		go_deep_copy.WithAllocator(go_deep_copy.NewSlabAllocator(16)),
+		go_deep_copy.WithParallel(4, 8),
+	)
+	source := newItems(64)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				var orders []Order
+				if err := copier.DeepCopy([]Order{{Customer: &User{Name: "a"}, Items: source}}, &orders); err != nil {
+					t.Errorf("Copy failed: %v", err)
+					return
+				}
+				if !reflect.DeepEqual(orders[0].Items, source) || orders[0].Customer.Name != "a" {
+					t.Error("concurrent copy mismatch")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
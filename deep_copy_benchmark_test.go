@@ -2,8 +2,11 @@ package go_deep_copy_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/LiZhiqiang0/go_deep_copy"
+	"github.com/LiZhiqiang0/reflect2"
 	"github.com/jinzhu/copier"
+	"reflect"
 	"testing"
 )
 
@@ -47,6 +50,8 @@ var author = Author{
 	Male: true,
 }
 
+var slabCopier = go_deep_copy.NewCopier(go_deep_copy.WithAllocator(go_deep_copy.NewSlabAllocator(1024)))
+
 func BenchmarkCopyStruct(b *testing.B) {
 
 	runs := []struct {
@@ -65,6 +70,12 @@ func BenchmarkCopyStruct(b *testing.B) {
 				go_deep_copy.DeepCopy(&book, &a)
 			},
 		},
+		{"go_deep_copy_slab",
+			func() {
+				a := Book{}
+				slabCopier.DeepCopy(&book, &a)
+			},
+		},
 		{"json",
 			func() {
 				data, _ := json.Marshal(book)
@@ -83,3 +94,56 @@ func BenchmarkCopyStruct(b *testing.B) {
 		})
 	}
 }
+
+// BenchmarkRCUStore 每次写入一个新键，单次写入的耗时不应随已有键的个数增长
+func BenchmarkRCUStore(b *testing.B) {
+	b.Run("map", func(b *testing.B) {
+		m := go_deep_copy.NewMapRCU()
+		for i := 0; i < b.N; i++ {
+			m.Store([2]uintptr{uintptr(i), 0}, i)
+		}
+	})
+	types := make([]reflect2.Type, 0, 20000)
+	for i := 0; i < cap(types); i++ {
+		types = append(types, reflect2.Type2(reflect.StructOf([]reflect.StructField{
+			{Name: fmt.Sprintf("Field%d", i), Type: reflect.TypeOf(0)},
+		})))
+	}
+	b.Run("liner", func(b *testing.B) {
+		m := go_deep_copy.NewLinerRCU()
+		for i := 0; i < b.N; i++ {
+			if i%len(types) == 0 {
+				m = go_deep_copy.NewLinerRCU()
+			}
+			m.Store(types[i%len(types)], i)
+		}
+	})
+}
+
+// BenchmarkCopyPointers 拷贝由大量小指针组成的值，slab 把每个指针目标的分配合并为少数几次大的分配；
+// BenchmarkCopyStruct 中的 Book 只有几个切片，省下的分配次数不足以体现差别
+func BenchmarkCopyPointers(b *testing.B) {
+	authors := make([]*Author, 1000)
+	for i := range authors {
+		a := author
+		a.Age = i
+		authors[i] = &a
+	}
+	runs := []struct {
+		name   string
+		copier *go_deep_copy.Copier
+	}{
+		{"go_deep_copy", go_deep_copy.NewCopier()},
+		{"go_deep_copy_slab", slabCopier},
+	}
+	for _, r := range runs {
+		b.Run(r.name, func(b *testing.B) {
+			b.ReportAllocs()
+			// 保留最近的拷贝，使 GC 每次都要扫描它们
+			kept := make([][]*Author, 64)
+			for i := 0; i < b.N; i++ {
+				r.copier.DeepCopy(&authors, &kept[i%len(kept)])
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package go_deep_copy_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+// TestCacheLimit 测试缓存容量上限与淘汰
+func TestCacheLimit(t *testing.T) {
+	const limit = 16
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithCacheLimit(limit))
+
+	for i := 0; i < 200; i++ {
+		typ := reflect.StructOf([]reflect.StructField{
+			{Name: fmt.Sprintf("Field%d", i), Type: reflect.TypeOf(0)},
+		})
+		from := reflect.New(typ)
+		from.Elem().Field(0).SetInt(int64(i))
+		to := reflect.New(typ)
+		err := copier.DeepCopy(from.Interface(), to.Interface())
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if to.Elem().Field(0).Int() != int64(i) {
+			t.Fatalf("Field%d not copied", i)
+		}
+	}
+
+	stats := copier.CacheStats()
+	if stats.Funcs > limit || stats.Structs > limit {
+		t.Errorf("cache exceeds limit %d: %+v", limit, stats)
+	}
+	if stats.Evictions == 0 {
+		t.Errorf("expected evictions: %+v", stats)
+	}
+	if stats.Compilations < 200 {
+		t.Errorf("expected at least 200 compilations: %+v", stats)
+	}
+}
+
+// TestCacheLimitMasks 测试字段掩码同样受缓存容量上限约束，并随 ResetCache 清空
+func TestCacheLimitMasks(t *testing.T) {
+	const limit = 16
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithCacheLimit(limit))
+
+	source := newMaskOrder()
+	for i := 0; i < 200; i++ {
+		source.Tags = map[string]string{fmt.Sprintf("k%d", i): "v"}
+		var target Order
+		err := copier.DeepCopyWithOptions(&source, &target, go_deep_copy.OnlyPaths("ID", fmt.Sprintf("Tags.k%d", i)))
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if want := (Order{ID: 7, Tags: source.Tags}); !reflect.DeepEqual(target, want) {
+			t.Fatalf("got %+v, want %+v", target, want)
+		}
+	}
+	if stats := copier.CacheStats(); stats.Masks == 0 || stats.Masks > limit || stats.Evictions == 0 {
+		t.Errorf("masks exceed limit %d: %+v", limit, stats)
+	}
+
+	copier.ResetCache()
+	if stats := copier.CacheStats(); stats.Masks != 0 {
+		t.Errorf("ResetCache should drop masks: %+v", stats)
+	}
+}
+
+// TestCacheStats 测试缓存命中统计与重置
+func TestCacheStats(t *testing.T) {
+	go_deep_copy.ResetCache()
+	if stats := go_deep_copy.CacheStats(); stats != (go_deep_copy.CacheStatistics{}) {
+		t.Fatalf("ResetCache should clear statistics: %+v", stats)
+	}
+
+	source := Class{Name: "Math", ID: 1}
+	var target Class
+	if err := go_deep_copy.DeepCopy(&source, &target); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	first := go_deep_copy.CacheStats()
+	if first.Misses == 0 || first.Compilations == 0 || first.Funcs == 0 || first.Structs == 0 {
+		t.Errorf("first copy should compile plans: %+v", first)
+	}
+
+	if err := go_deep_copy.DeepCopy(&source, &target); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	second := go_deep_copy.CacheStats()
+	if second.Hits <= first.Hits || second.Compilations != first.Compilations {
+		t.Errorf("second copy should only hit the cache: first %+v, second %+v", first, second)
+	}
+}
@@ -0,0 +1,105 @@
+package go_deep_copy_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+type ProfileSource struct {
+	Name    string
+	Age     int64
+	Score   float64
+	Extra   string
+	Tags    []string
+	Friends []ProfileSource
+}
+
+type ProfileTarget struct {
+	Name    string
+	Age     int32
+	Score   int
+	Tags    [2]string
+	Missing bool
+	Friends []*ProfileTarget
+}
+
+// recordingT 记录 RequireFullCoverage 报告的错误
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// TestCheck 测试两个类型之间的静态兼容性报告
+func TestCheck(t *testing.T) {
+	report := go_deep_copy.Check(reflect.TypeOf(ProfileSource{}), reflect.TypeOf(ProfileTarget{}))
+
+	// Friends 递归回到 ProfileSource -> ProfileTarget，不再重复展开
+	wantFilled := []string{"Name", "Age", "Score", "Tags", "Friends"}
+	if !reflect.DeepEqual(report.Filled, wantFilled) {
+		t.Errorf("Filled mismatch:\ngot  %v\nwant %v", report.Filled, wantFilled)
+	}
+	if want := []string{"Missing"}; !reflect.DeepEqual(report.Unfilled, want) {
+		t.Errorf("Unfilled mismatch: got %v, want %v", report.Unfilled, want)
+	}
+	if want := []string{"Extra"}; !reflect.DeepEqual(report.Unmatched, want) {
+		t.Errorf("Unmatched mismatch: got %v, want %v", report.Unmatched, want)
+	}
+	var lossy []string
+	for _, cvt := range report.Lossy {
+		lossy = append(lossy, cvt.Path)
+	}
+	if want := []string{"Age", "Score", "Tags"}; !reflect.DeepEqual(lossy, want) {
+		t.Errorf("Lossy mismatch: got %v, want %v", lossy, want)
+	}
+	if len(report.Unsupported) != 0 || report.FullCoverage() {
+		t.Errorf("unexpected coverage: %s", report)
+	}
+
+	report = go_deep_copy.Check(reflect.TypeOf(Order{}), reflect.TypeOf(OrderDTO{}))
+	filled := map[string]bool{}
+	for _, path := range report.Filled {
+		filled[path] = true
+	}
+	if !filled["Items[*].SKU"] || !filled["Customer.Name"] {
+		t.Errorf("nested fields should be filled: %v", report.Filled)
+	}
+
+	report = go_deep_copy.Check(reflect.TypeOf(Order{}), reflect.TypeOf(BadOrderDTO{}))
+	if len(report.Unsupported) != 2 || report.Unsupported[0].Path != "ID" || report.Unsupported[1].Path != "Items[*].SKU" {
+		t.Errorf("Unsupported mismatch: %s", report)
+	}
+
+	// 复数拷贝到接口不会损失精度，也不会 panic
+	type complexSource struct{ C complex128 }
+	report = go_deep_copy.Check(reflect.TypeOf(complexSource{}), reflect.TypeOf(map[string]interface{}{}))
+	if len(report.Lossy) != 0 || len(report.Unsupported) != 0 {
+		t.Errorf("complex to interface should be lossless: %s", report)
+	}
+	report = go_deep_copy.Check(reflect.TypeOf(complexSource{}), reflect.TypeOf(struct{ C interface{} }{}))
+	if len(report.Lossy) != 0 || !report.FullCoverage() {
+		t.Errorf("complex to interface should be lossless: %s", report)
+	}
+	report = go_deep_copy.Check(reflect.TypeOf(complexSource{}), reflect.TypeOf(struct{ C complex64 }{}))
+	if len(report.Lossy) != 1 || report.Lossy[0].Path != "C" {
+		t.Errorf("complex128 to complex64 should be lossy: %s", report)
+	}
+}
+
+// TestRequireFullCoverage 测试覆盖率断言
+func TestRequireFullCoverage(t *testing.T) {
+	go_deep_copy.RequireFullCoverage(t, reflect.TypeOf(User{}), reflect.TypeOf(User{}))
+
+	rt := &recordingT{}
+	go_deep_copy.RequireFullCoverage(rt, reflect.TypeOf(ProfileSource{}), reflect.TypeOf(ProfileTarget{}))
+	if len(rt.errors) != 1 {
+		t.Fatalf("expected one error, got %v", rt.errors)
+	}
+}
@@ -0,0 +1,200 @@
+package go_deep_copy_test
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+type TreeA struct {
+	Name  string
+	B     *TreeB
+	Items []TreeA
+}
+
+type TreeB struct {
+	ID  int
+	A   *TreeA
+	Map map[string]*TreeB
+}
+
+// TestRCUStore 测试 Store 写入新键与覆盖已有键
+func TestRCUStore(t *testing.T) {
+	t.Run("map rcu", func(t *testing.T) {
+		m := go_deep_copy.NewMapRCU()
+		key := [2]uintptr{1, 2}
+		m.Store(key, "a")
+		if v, ok := m.Load(key); !ok || v != "a" {
+			t.Fatalf("Store did not insert: got %v, %v", v, ok)
+		}
+		m.Store(key, "b")
+		if v, _ := m.Load(key); v != "b" || m.Len() != 1 {
+			t.Errorf("Store did not overwrite: got %v, len %d", v, m.Len())
+		}
+	})
+
+	t.Run("liner rcu", func(t *testing.T) {
+		m := go_deep_copy.NewLinerRCU()
+		types := make([]reflect2.Type, 0, 2500)
+		for i := 0; i < cap(types); i++ {
+			typ := reflect.StructOf([]reflect.StructField{
+				{Name: fmt.Sprintf("Field%d", i), Type: reflect.TypeOf("")},
+			})
+			types = append(types, reflect2.Type2(typ))
+			m.Store(types[i], i)
+		}
+		m.Store(types[0], -1)
+		if m.Len() != len(types) {
+			t.Errorf("Len mismatch: got %d, want %d", m.Len(), len(types))
+		}
+		for i, typ := range types {
+			want := i
+			if i == 0 {
+				want = -1
+			}
+			if v, ok := m.Load(typ); !ok || v != want {
+				t.Fatalf("Load %s: got %v, want %d", typ, v, want)
+			}
+		}
+	})
+}
+
+// TestRCUConcurrentGrowth 测试并发读取时持续写入新键，新键在合并进快照前后都能读到，覆盖不丢失
+func TestRCUConcurrentGrowth(t *testing.T) {
+	const n = 5000
+	m := go_deep_copy.NewMapRCU()
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				for i := 0; i < n; i += 97 {
+					if v, ok := m.Load([2]uintptr{uintptr(i), 0}); ok && v.(int) != i && v.(int) != -i {
+						t.Errorf("Load %d: got %v", i, v)
+						return
+					}
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		key := [2]uintptr{uintptr(i), 0}
+		if _, loaded := m.LoadOrStore(key, i); loaded {
+			t.Fatalf("LoadOrStore %d: key already present", i)
+		}
+		if v, ok := m.Load(key); !ok || v != i {
+			t.Fatalf("Load %d after insert: got %v, %v", i, v, ok)
+		}
+		// 覆盖刚写入与早已写入的键
+		m.Store(key, -i)
+		m.Store([2]uintptr{uintptr(i / 2), 0}, -(i / 2))
+	}
+	close(done)
+	wg.Wait()
+	if m.Len() != n {
+		t.Errorf("Len mismatch: got %d, want %d", m.Len(), n)
+	}
+	for i := 0; i < n; i++ {
+		if v, ok := m.Load([2]uintptr{uintptr(i), 0}); !ok || v != -i {
+			t.Fatalf("Load %d: got %v, %v", i, v, ok)
+		}
+	}
+	if evicted := m.Evict(func(v any) bool { return v.(int)%2 == 0 }); evicted != n/2 || m.Len() != n/2 {
+		t.Errorf("Evict mismatch: evicted %d, len %d", evicted, m.Len())
+	}
+}
+
+// TestConcurrentLoadConvertFunc 测试多协程并发构建与执行转换函数，递归类型不应死锁
+func TestConcurrentLoadConvertFunc(t *testing.T) {
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithInterfaceMode(go_deep_copy.InterfacePreserve))
+
+	type pair struct {
+		from, to interface{}
+	}
+	pairs := []pair{
+		{&TreeA{Name: "a", B: &TreeB{ID: 1, Map: map[string]*TreeB{"b": {ID: 2}}}, Items: []TreeA{{Name: "c"}}}, &TreeA{}},
+		{&TreeB{ID: 1, A: &TreeA{Name: "a"}}, &TreeB{}},
+		{&TreeA{Name: "a", B: &TreeB{ID: 1}}, &map[string]interface{}{}},
+		{&Node{Name: "a", Next: &Node{Name: "b"}, Value: &Node{Name: "c"}}, &Node{}},
+		{&User{Name: "Alice", Age: 30, Class: &Class{Name: "Math"}}, &Employee{}},
+		{&map[string]interface{}{"Name": "a", "B": map[string]interface{}{"ID": 1}}, &TreeA{}},
+		{&[]interface{}{int32(1), "a", &Node{Name: "n"}}, &[]interface{}{}},
+	}
+	for i := 0; i < 50; i++ {
+		typ := reflect.StructOf([]reflect.StructField{
+			{Name: fmt.Sprintf("Field%d", i), Type: reflect.TypeOf(0)},
+			{Name: "Next", Type: reflect.TypeOf(&TreeA{})},
+		})
+		from := reflect.New(typ)
+		from.Elem().Field(0).SetInt(int64(i + 1))
+		pairs = append(pairs, pair{from.Interface(), reflect.New(typ).Interface()})
+	}
+
+	const goroutines = 32
+	done := make(chan struct{})
+	errs := make(chan error, goroutines)
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := range pairs {
+					p := pairs[(i+g)%len(pairs)]
+					fromType := reflect2.TypeOf(p.from).(*reflect2.UnsafePtrType).Elem()
+					toType := reflect2.TypeOf(p.to).(*reflect2.UnsafePtrType).Elem()
+					to := toType.New()
+					err := copier.LoadConvertFunc(fromType, toType)(
+						rt.Value{Typ: fromType, Ptr: reflect2.PtrOf(p.from)},
+						rt.Value{Typ: toType, Ptr: reflect2.PtrOf(to)},
+					)
+					if err != nil {
+						errs <- fmt.Errorf("%s -> %s: %w", fromType, toType, err)
+						return
+					}
+					if toType.Kind() != reflect.Struct {
+						continue
+					}
+					field := reflect.ValueOf(to).Elem().FieldByName("Name")
+					if !field.IsValid() {
+						field = reflect.ValueOf(to).Elem().Field(0)
+					}
+					if field.IsZero() {
+						errs <- fmt.Errorf("%s -> %s: field %s not copied", fromType, toType, field.Type())
+						return
+					}
+				}
+			}(g)
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("LoadConvertFunc deadlocked")
+	}
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	stats := copier.CacheStats()
+	if stats.Compilations != uint64(stats.Funcs) {
+		t.Errorf("every plan should be compiled once and kept: %+v", stats)
+	}
+}
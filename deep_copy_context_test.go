@@ -0,0 +1,136 @@
+package go_deep_copy_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+type Samples struct {
+	Name   string
+	Values []int64
+}
+
+func contextError(t *testing.T, err error, target error) *go_deep_copy.ContextError {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("expected %v, got %v", target, err)
+	}
+	var ctxErr *go_deep_copy.ContextError
+	if !errors.As(err, &ctxErr) {
+		t.Fatalf("expected *ContextError, got %T", err)
+	}
+	return ctxErr
+}
+
+// TestDeepCopyContext 测试 ctx 未取消时与 DeepCopy 相同
+func TestDeepCopyContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	source := newMaskOrder()
+	var target Order
+	if err := go_deep_copy.DeepCopyContext(ctx, &source, &target); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if !reflect.DeepEqual(target, source) {
+		t.Errorf("got %+v, want %+v", target, source)
+	}
+
+	var background Order
+	if err := go_deep_copy.DeepCopyContext(context.Background(), &source, &background); err != nil || !reflect.DeepEqual(background, source) {
+		t.Errorf("copy with background context mismatch: %v, %+v", err, background)
+	}
+}
+
+// TestDeepCopyContextCanceled 测试 ctx 取消后中止拷贝并返回所在路径
+func TestDeepCopyContextCanceled(t *testing.T) {
+	t.Run("before copy", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		source := newMaskOrder()
+		var target Order
+		ctxErr := contextError(t, go_deep_copy.DeepCopyContext(ctx, &source, &target), context.Canceled)
+		if ctxErr.Path != "" || !reflect.DeepEqual(target, Order{}) {
+			t.Errorf("unexpected result: %v, %+v", ctxErr, target)
+		}
+	})
+
+	t.Run("during copy", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		// 拷贝到第 500 个元素时取消
+		mapping := go_deep_copy.NewMapping[OrderItem, OrderItem]().
+			Convert("SKU", func(sku string) string {
+				if sku == "500" {
+					cancel()
+				}
+				return sku
+			})
+		copier := go_deep_copy.NewCopier(go_deep_copy.WithMapping(mapping))
+
+		source := Order{ID: 1}
+		for i := 0; i < 2000; i++ {
+			source.Items = append(source.Items, OrderItem{SKU: strconv.Itoa(i)})
+		}
+		var target Order
+		ctxErr := contextError(t, copier.DeepCopyContext(ctx, &source, &target), context.Canceled)
+		match := regexp.MustCompile(`^Items\[(\d+)\]$`).FindStringSubmatch(ctxErr.Path)
+		if match == nil {
+			t.Fatalf("unexpected path: %q", ctxErr.Path)
+		}
+		if i, _ := strconv.Atoi(match[1]); i <= 500 || i >= 2000 {
+			t.Errorf("copy should stop soon after cancellation, stopped at %d", i)
+		}
+		// 已拷贝的字段保留，未完成的切片不写入目标
+		if target.ID != 1 || target.Items != nil {
+			t.Errorf("unexpected destination: %d, %d items", target.ID, len(target.Items))
+		}
+	})
+
+	t.Run("scalar slice", func(t *testing.T) {
+		source := Samples{Name: "cpu", Values: make([]int64, 1<<20)}
+		for _, parallel := range []bool{false, true} {
+			ctx, cancel := context.WithCancel(context.Background())
+			// 拷贝 Values 之前取消，标量元素之间同样会检查 ctx
+			mapping := go_deep_copy.NewMapping[Samples, Samples]().
+				Convert("Name", func(name string) string {
+					cancel()
+					return name
+				})
+			opts := []go_deep_copy.Option{go_deep_copy.WithMapping(mapping)}
+			if parallel {
+				opts = append(opts, go_deep_copy.WithParallel(4, 1024))
+			}
+			var target Samples
+			err := go_deep_copy.NewCopier(opts...).DeepCopyContext(ctx, &source, &target)
+			cancel()
+			ctxErr := contextError(t, err, context.Canceled)
+			match := regexp.MustCompile(`^Values\[(\d+)\]$`).FindStringSubmatch(ctxErr.Path)
+			if match == nil {
+				t.Fatalf("parallel %v: unexpected path: %q", parallel, ctxErr.Path)
+			}
+			if i, _ := strconv.Atoi(match[1]); i > 256 {
+				t.Errorf("parallel %v: copy should stop soon after cancellation, stopped at %d", parallel, i)
+			}
+			if target.Values != nil {
+				t.Errorf("parallel %v: the unfinished slice should not be written", parallel)
+			}
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+		<-ctx.Done()
+		source := map[string]interface{}{"a": []interface{}{1, 2}}
+		var target map[string]interface{}
+		contextError(t, go_deep_copy.DeepCopyContext(ctx, &source, &target), context.DeadlineExceeded)
+	})
+}
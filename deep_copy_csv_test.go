@@ -0,0 +1,191 @@
+package go_deep_copy_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+type CSVAddress struct {
+	City string `go_deep_copy:"city"`
+	Zip  *int   `go_deep_copy:"zip"`
+}
+
+type CSVRow struct {
+	ID     int    `go_deep_copy:"id"`
+	Name   string `go_deep_copy:"name,required"`
+	Score  float64
+	Active bool
+	Wait   time.Duration
+	At     time.Time
+	Note   *string
+	Addr   *CSVAddress `go_deep_copy:"addr"`
+	Level  string      `go_deep_copy:"level,default=basic"`
+	secret string
+}
+
+const csvRows = `id,name,Score,Active,Wait,At,Note,addr.city,addr.zip,level
+1,Alice,9.5,true,1m30s,2024-01-02T03:04:05Z,vip,Paris,75001,gold
+2,Bob,-0.25,false,0s,0001-01-01T00:00:00Z,,,,basic
+`
+
+// TestCSVEncode 测试按字段名称把结构体逐行写为 CSV
+func TestCSVEncode(t *testing.T) {
+	var buf bytes.Buffer
+	enc := go_deep_copy.NewCSVEncoder(csv.NewWriter(&buf))
+	for _, row := range newCSVRows() {
+		if err := enc.Encode(row); err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if buf.String() != csvRows {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), csvRows)
+	}
+
+	if err := enc.Encode(&User{}); err == nil {
+		t.Error("rows of another type should fail")
+	}
+
+	// 没有数据行时也可以只写表头
+	buf.Reset()
+	enc = go_deep_copy.NewCSVEncoder(csv.NewWriter(&buf))
+	if err := enc.WriteHeader((*CSVAddress)(nil)); !errors.Is(err, go_deep_copy.ErrInvalidCopyDestination) {
+		t.Errorf("expected ErrInvalidCopyDestination, got %v", err)
+	}
+	if err := enc.WriteHeader(CSVAddress{}); err != nil || enc.Flush() != nil || buf.String() != "city,zip\n" {
+		t.Errorf("unexpected header: %v, %q", err, buf.String())
+	}
+
+	enc = go_deep_copy.NewCSVEncoder(csv.NewWriter(&buf))
+	if err := enc.Encode(Order{}); !errors.Is(err, go_deep_copy.ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
+
+// TestCSVDecode 测试按表头逐行读取 CSV 到结构体
+func TestCSVDecode(t *testing.T) {
+	dec := go_deep_copy.NewCSVDecoder(csv.NewReader(strings.NewReader(csvRows)))
+	var rows []CSVRow
+	for {
+		row := CSVRow{secret: "reset"}
+		err := dec.Decode(&row)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		rows = append(rows, row)
+	}
+	want := newCSVRows()
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %+v, want %+v", rows, want)
+	}
+
+	// 列的顺序与表头一致即可，缺少的列按默认值写入
+	dec = go_deep_copy.NewCSVDecoder(csv.NewReader(strings.NewReader("name,id\nCarol,3\n")))
+	var row CSVRow
+	if err := dec.Decode(&row); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if row.ID != 3 || row.Name != "Carol" || row.Level != "basic" || row.Addr != nil {
+		t.Errorf("unexpected row: %+v", row)
+	}
+}
+
+// TestCSVDecodeErrors 测试读取 CSV 时的错误
+func TestCSVDecodeErrors(t *testing.T) {
+	dec := go_deep_copy.NewCSVDecoder(csv.NewReader(strings.NewReader("id,name\n1,Alice\nx,Bob\n2,\n")))
+	var row CSVRow
+	if err := dec.Decode(&row); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	err := dec.Decode(&row)
+	if !errors.Is(err, strconv.ErrSyntax) || !strings.Contains(err.Error(), "line 3, column id") {
+		t.Errorf("expected a syntax error on line 3, got %v", err)
+	}
+	if err := dec.Decode(&row); !errors.Is(err, go_deep_copy.ErrMissingField) {
+		t.Errorf("expected ErrMissingField for an empty required cell, got %v", err)
+	}
+
+	dec = go_deep_copy.NewCSVDecoder(csv.NewReader(strings.NewReader("id\n1\n")))
+	for i := 0; i < 2; i++ {
+		if err := dec.Decode(&row); !errors.Is(err, go_deep_copy.ErrMissingField) {
+			t.Errorf("expected ErrMissingField for a missing required column, got %v", err)
+		}
+	}
+
+	strict := go_deep_copy.NewCopier(go_deep_copy.WithDisallowUnknownFields())
+	dec = strict.NewCSVDecoder(csv.NewReader(strings.NewReader("name,extra\nAlice,1\n")))
+	if err := dec.Decode(&row); !errors.Is(err, go_deep_copy.ErrUnknownField) {
+		t.Errorf("expected ErrUnknownField, got %v", err)
+	}
+
+	if err := go_deep_copy.NewCSVDecoder(csv.NewReader(strings.NewReader(csvRows))).Decode(row); !errors.Is(err, go_deep_copy.ErrInvalidCopyDestination) {
+		t.Errorf("expected ErrInvalidCopyDestination, got %v", err)
+	}
+}
+
+// TestCSVRedaction 测试 CSV 导出时的脱敏
+func TestCSVRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithRedaction(go_deep_copy.RedactMask))
+	enc := copier.NewCSVEncoder(csv.NewWriter(&buf))
+	if err := enc.Encode(Credentials{Token: "t"}); err != nil || enc.Flush() != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if buf.String() != "Token\n***\n" {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+
+	buf.Reset()
+	copier = go_deep_copy.NewCopier(go_deep_copy.WithRedaction(go_deep_copy.RedactDrop))
+	enc = copier.NewCSVEncoder(csv.NewWriter(&buf))
+	if err := enc.Encode(LoginLog{User: "alice"}); err != nil || enc.Flush() != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	// Auth.Token 标记为 sensitive，整列省略
+	if buf.String() != "User,Password,PIN,key\nalice,,0,\n" {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
+
+type CSVOrderRow struct {
+	ID   int    `go_deep_copy:"id"`
+	City string `go_deep_copy:"Customer.City,required"`
+	Zip  *int   `go_deep_copy:"Customer.Zip"`
+	Tier string `go_deep_copy:"Customer.Tier,default=basic"`
+}
+
+// TestCSVDottedTags 测试标签名本身含点号的字段
+func TestCSVDottedTags(t *testing.T) {
+	zip := 75001
+	var buf bytes.Buffer
+	enc := go_deep_copy.NewCSVEncoder(csv.NewWriter(&buf))
+	if err := enc.Encode(CSVOrderRow{ID: 1, City: "Paris", Zip: &zip, Tier: "gold"}); err != nil || enc.Flush() != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if want := "id,Customer.City,Customer.Zip,Customer.Tier\n1,Paris,75001,gold\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	dec := go_deep_copy.NewCSVDecoder(csv.NewReader(strings.NewReader("id,Customer.City,Customer.Zip\n1,Paris,75001\n")))
+	var row CSVOrderRow
+	if err := dec.Decode(&row); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if want := (CSVOrderRow{ID: 1, City: "Paris", Zip: &zip, Tier: "basic"}); !reflect.DeepEqual(row, want) {
+		t.Errorf("got %+v, want %+v", row, want)
+	}
+}
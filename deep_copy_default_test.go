@@ -0,0 +1,126 @@
+package go_deep_copy_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+type ServerConfig struct {
+	Host    string        `go_deep_copy:"host,default=localhost"`
+	Port    int           `go_deep_copy:"port,default=8080"`
+	Timeout time.Duration `go_deep_copy:"timeout,default=30s"`
+	Debug   *bool         `go_deep_copy:"debug,default=true"`
+	Ratio   float64       `go_deep_copy:"ratio"`
+}
+
+type ServerFlags struct {
+	Host *string `go_deep_copy:"host"`
+	Port int     `go_deep_copy:"port"`
+}
+
+type BadDefaultConfig struct {
+	Port int `go_deep_copy:"port,default=abc"`
+}
+
+type ListDefaults struct {
+	Greeting string   `go_deep_copy:"greeting,default='hello, required'"`
+	Tags     []string `go_deep_copy:"tags,default='a, b',sensitive"`
+	Ports    []int    `go_deep_copy:"ports,default=80"`
+	Empty    []string `go_deep_copy:"empty,default="`
+}
+
+// TestDefaultValues 测试标签中默认值的解析与写入
+func TestDefaultValues(t *testing.T) {
+	t.Run("map to struct", func(t *testing.T) {
+		source := map[string]interface{}{"port": 9090, "host": nil, "ratio": 0.5}
+		var target ServerConfig
+		err := go_deep_copy.DeepCopy(&source, &target)
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if target.Host != "localhost" || target.Port != 9090 || target.Timeout != 30*time.Second || target.Ratio != 0.5 {
+			t.Errorf("defaults not applied: %+v", target)
+		}
+		if target.Debug == nil || !*target.Debug {
+			t.Fatalf("Debug default not applied: %v", target.Debug)
+		}
+
+		var other ServerConfig
+		err = go_deep_copy.DeepCopy(&map[string]string{"timeout": "5s"}, &other)
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if other.Timeout != 5*time.Second || other.Port != 8080 {
+			t.Errorf("timeout should be parsed from the source: %+v", other)
+		}
+		if other.Debug == target.Debug {
+			t.Error("default values should not be shared between copies")
+		}
+	})
+
+	t.Run("struct to struct", func(t *testing.T) {
+		var target ServerConfig
+		err := go_deep_copy.DeepCopy(&ServerFlags{}, &target)
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if target.Host != "localhost" || target.Port != 0 || target.Timeout != 30*time.Second {
+			t.Errorf("defaults should apply to absent and nil fields only: %+v", target)
+		}
+
+		host := "example.com"
+		err = go_deep_copy.DeepCopy(&ServerFlags{Host: &host, Port: 1}, &target)
+		if err != nil || target.Host != "example.com" || target.Port != 1 {
+			t.Errorf("source values should win: %v, %+v", err, target)
+		}
+	})
+
+	t.Run("invalid default", func(t *testing.T) {
+		var target BadDefaultConfig
+		err := go_deep_copy.DeepCopy(&map[string]interface{}{}, &target)
+		var defaultErr *go_deep_copy.DefaultError
+		if !errors.As(err, &defaultErr) || defaultErr.Field != "port" {
+			t.Errorf("expected DefaultError, got %v", err)
+		}
+		if !errors.As(go_deep_copy.Warm[ServerFlags, BadDefaultConfig](), &defaultErr) {
+			t.Error("Warm should report the invalid default")
+		}
+	})
+	t.Run("quoted and list defaults", func(t *testing.T) {
+		// 引号中的逗号属于默认值，其后的选项照常解析
+		var target ListDefaults
+		if err := go_deep_copy.DeepCopy(&map[string]interface{}{}, &target); err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		want := ListDefaults{Greeting: "hello, required", Tags: []string{"a", "b"}, Ports: []int{80}}
+		if !reflect.DeepEqual(target, want) {
+			t.Errorf("got %+v, want %+v", target, want)
+		}
+		redacted := go_deep_copy.NewCopier(go_deep_copy.WithRedaction(go_deep_copy.RedactDrop))
+		var dropped ListDefaults
+		if err := redacted.DeepCopy(&ListDefaults{Tags: []string{"x"}}, &dropped); err != nil || dropped.Tags != nil {
+			t.Errorf("Tags should stay sensitive: %v, %+v", err, dropped.Tags)
+		}
+	})
+
+	t.Run("invalid quoted and list defaults", func(t *testing.T) {
+		type Unterminated struct {
+			Name string `go_deep_copy:"name,default='abc"`
+		}
+		type BadList struct {
+			Ports []int `go_deep_copy:"ports,default='80,x'"`
+		}
+		var defaultErr *go_deep_copy.DefaultError
+		if err := go_deep_copy.DeepCopy(&map[string]interface{}{}, &Unterminated{}); !errors.As(err, &defaultErr) || defaultErr.Field != "name" {
+			t.Errorf("expected DefaultError for name, got %v", err)
+		}
+		if err := go_deep_copy.DeepCopy(&map[string]interface{}{}, &BadList{}); !errors.As(err, &defaultErr) || defaultErr.Field != "ports" || !strings.Contains(err.Error(), "BadList.ports") {
+			t.Errorf("expected DefaultError for Ports, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package go_deep_copy_test
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+type EnvDB struct {
+	Host     string
+	Port     int
+	Password string `go_deep_copy:",sensitive"`
+}
+
+type EnvConfig struct {
+	Name      string `go_deep_copy:"name,required"`
+	Debug     bool
+	Ratio     float32
+	Timeout   time.Duration `go_deep_copy:"timeout,default=5s"`
+	Hosts     []string      `go_deep_copy:"hosts"`
+	Ports     []uint16      `go_deep_copy:"ports"`
+	Backoff   []time.Duration
+	Started   time.Time
+	DB        EnvDB
+	Replica   *EnvDB
+	MaxConns  *int `go_deep_copy:"max_conns"`
+	secretKey string
+}
+
+// TestBindEnv 测试按前缀与 __ 分隔的键绑定配置结构体
+func TestBindEnv(t *testing.T) {
+	env := newEnv()
+	env["APP_PORTS"] = "80, 443"
+	env["PATH"] = "/usr/bin"
+	var cfg EnvConfig
+	if err := go_deep_copy.BindEnv(env, "APP_", &cfg); err != nil {
+		t.Fatalf("BindEnv failed: %v", err)
+	}
+	if want := newEnvConfig(); !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got %+v, want %+v", cfg, want)
+	}
+
+	// 没有的键保持原值，空值与没有的键相同，default 标签的字段写入默认值
+	cfg = EnvConfig{Ratio: 2}
+	environ := []string{"APP_NAME=web", "APP_HOSTS=", "APP_REPLICA__HOST=replica", "APP_EMPTY=", "invalid"}
+	if err := go_deep_copy.BindEnv(go_deep_copy.ParseEnviron(environ), "APP_", &cfg); err != nil {
+		t.Fatalf("BindEnv failed: %v", err)
+	}
+	if cfg.Name != "web" || cfg.Ratio != 2 || cfg.Timeout != 5*time.Second || cfg.Hosts != nil || cfg.Replica == nil || cfg.Replica.Host != "replica" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+}
+
+// TestBindEnvErrors 测试绑定环境变量时的错误
+func TestBindEnvErrors(t *testing.T) {
+	var cfg EnvConfig
+	err := go_deep_copy.BindEnv(map[string]string{"APP_NAME": "api", "APP_PORTS": "80,x"}, "APP_", &cfg)
+	if !errors.Is(err, strconv.ErrSyntax) || !strings.Contains(err.Error(), "env APP_PORTS") {
+		t.Errorf("expected a syntax error for APP_PORTS, got %v", err)
+	}
+	err = go_deep_copy.BindEnv(map[string]string{"APP_NAME": "api", "APP_TIMEOUT": "soon"}, "APP_", &cfg)
+	if err == nil || !strings.Contains(err.Error(), "env APP_TIMEOUT") {
+		t.Errorf("expected an error for APP_TIMEOUT, got %v", err)
+	}
+
+	var fieldsErr *go_deep_copy.FieldsError
+	err = go_deep_copy.BindEnv(map[string]string{"APP_NAME": ""}, "APP_", &cfg)
+	if !errors.As(err, &fieldsErr) || !reflect.DeepEqual(fieldsErr.Missing, []string{"APP_NAME"}) {
+		t.Errorf("expected APP_NAME to be missing, got %v", err)
+	}
+	if err := go_deep_copy.BindEnv(nil, "", cfg); !errors.Is(err, go_deep_copy.ErrInvalidCopyDestination) {
+		t.Errorf("expected ErrInvalidCopyDestination, got %v", err)
+	}
+
+	strict := go_deep_copy.NewCopier(go_deep_copy.WithDisallowUnknownFields())
+	err = strict.BindEnv(map[string]string{"APP_NAME": "api", "APP_DB__USER": "u", "HOME": "/root"}, "APP_", &cfg)
+	if !errors.As(err, &fieldsErr) || !reflect.DeepEqual(fieldsErr.Unknown, []string{"APP_DB__USER"}) {
+		t.Errorf("expected APP_DB__USER to be unknown, got %v", err)
+	}
+}
+
+// TestFlattenEnv 测试把配置结构体展开为环境变量
+func TestFlattenEnv(t *testing.T) {
+	cfg := newEnvConfig()
+	env, err := go_deep_copy.FlattenEnv(&cfg, "APP_")
+	if err != nil {
+		t.Fatalf("FlattenEnv failed: %v", err)
+	}
+	want := newEnv()
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("got %v, want %v", env, want)
+	}
+
+	// 展开的结果可以绑定回相同的结构体
+	var back EnvConfig
+	if err := go_deep_copy.BindEnv(env, "APP_", &back); err != nil || !reflect.DeepEqual(back, cfg) {
+		t.Errorf("round trip failed: %v, %+v", err, back)
+	}
+
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithRedaction(go_deep_copy.RedactMask))
+	if env, err := copier.FlattenEnv(cfg, ""); err != nil || env["DB__PASSWORD"] != "***" || env["DB__HOST"] != "db" {
+		t.Errorf("unexpected env: %v, %v", err, env)
+	}
+
+	if _, err := go_deep_copy.FlattenEnv(Order{}, ""); !errors.Is(err, go_deep_copy.ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
+
+// TestEnvDottedTags 测试标签名本身含点号的字段
+func TestEnvDottedTags(t *testing.T) {
+	zip := 75001
+	env, err := go_deep_copy.FlattenEnv(CSVOrderRow{ID: 1, City: "Paris", Zip: &zip, Tier: "gold"}, "APP_")
+	want := map[string]string{"APP_ID": "1", "APP_CUSTOMER__CITY": "Paris", "APP_CUSTOMER__ZIP": "75001", "APP_CUSTOMER__TIER": "gold"}
+	if err != nil || !reflect.DeepEqual(env, want) {
+		t.Errorf("got %v, %v, want %v", env, err, want)
+	}
+
+	var row CSVOrderRow
+	if err := go_deep_copy.BindEnv(map[string]string{"APP_CUSTOMER__CITY": "Paris", "APP_CUSTOMER__ZIP": "75001"}, "APP_", &row); err != nil {
+		t.Fatalf("BindEnv failed: %v", err)
+	}
+	if want := (CSVOrderRow{City: "Paris", Zip: &zip, Tier: "basic"}); !reflect.DeepEqual(row, want) {
+		t.Errorf("got %+v, want %+v", row, want)
+	}
+}
@@ -0,0 +1,108 @@
+package go_deep_copy_test
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+func newMaskOrder() Order {
+	return Order{
+		ID:       7,
+		Customer: &User{Name: "Alice", Age: 30},
+		Items:    []OrderItem{{SKU: "a", Count: 1}, {SKU: "b", Count: 2}},
+		Tags:     map[string]string{"k": "v", "x": "y"},
+	}
+}
+
+func newItems(n int) []OrderItem {
+	items := make([]OrderItem, n)
+	for i := range items {
+		items[i] = OrderItem{SKU: strconv.Itoa(i), Count: int32(i)}
+	}
+	return items
+}
+
+func newCSVRows() []CSVRow {
+	zip := 75001
+	note := "vip"
+	return []CSVRow{
+		{ID: 1, Name: "Alice", Score: 9.5, Active: true, Wait: 90 * time.Second,
+			At: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Note: &note, Addr: &CSVAddress{City: "Paris", Zip: &zip}, Level: "gold"},
+		{ID: 2, Name: "Bob", Score: -0.25, Level: "basic"},
+	}
+}
+
+func newFormRequest() FormRequest {
+	zip := 75001
+	qty := int32(3)
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	return FormRequest{
+		Name:   "Alice",
+		Age:    30,
+		Tags:   []string{"a", "b"},
+		Scores: []float64{1.5, 2},
+		Wait:   time.Minute,
+		At:     &at,
+		Addr:   &CSVAddress{City: "Paris", Zip: &zip},
+		Items:  []FormItem{{ID: 7, Qty: &qty}, {ID: 8}},
+		Labels: map[string]string{"env": "prod"},
+		Level:  "gold",
+	}
+}
+
+func newEnvConfig() EnvConfig {
+	maxConns := 10
+	return EnvConfig{
+		Name:     "api",
+		Debug:    true,
+		Ratio:    0.5,
+		Timeout:  30 * time.Second,
+		Hosts:    []string{"a.example", "b.example"},
+		Ports:    []uint16{80, 443},
+		Backoff:  []time.Duration{time.Second, 2 * time.Second},
+		Started:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		DB:       EnvDB{Host: "db", Port: 5432, Password: "pw"},
+		MaxConns: &maxConns,
+	}
+}
+
+func newEnv() map[string]string {
+	return map[string]string{
+		"APP_NAME":         "api",
+		"APP_DEBUG":        "true",
+		"APP_RATIO":        "0.5",
+		"APP_TIMEOUT":      "30s",
+		"APP_HOSTS":        "a.example,b.example",
+		"APP_PORTS":        "80,443",
+		"APP_BACKOFF":      "1s,2s",
+		"APP_STARTED":      "2024-01-02T03:04:05Z",
+		"APP_DB__HOST":     "db",
+		"APP_DB__PORT":     "5432",
+		"APP_DB__PASSWORD": "pw",
+		"APP_MAX_CONNS":    "10",
+	}
+}
+
+func newLoginRequest() LoginRequest {
+	return LoginRequest{User: "alice", Password: "secret", PIN: 1234, Key: []byte("k"), Auth: &Credentials{Token: "t"}}
+}
+
+func newContactCopier() *go_deep_copy.Copier {
+	return go_deep_copy.NewCopier(
+		go_deep_copy.WithTransform("mask", func(s string) (string, error) {
+			if len(s) <= 4 {
+				return s, nil
+			}
+			return strings.Repeat("*", len(s)-4) + s[len(s)-4:], nil
+		}),
+		go_deep_copy.WithTransform("positive", func(i int) (int, error) {
+			if i < 0 {
+				return 0, errNegative
+			}
+			return i, nil
+		}),
+	)
+}
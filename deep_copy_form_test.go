@@ -0,0 +1,157 @@
+package go_deep_copy_test
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+type FormItem struct {
+	ID  int `go_deep_copy:"id"`
+	Qty *int32
+}
+
+type FormRequest struct {
+	Name   string `go_deep_copy:"name,required"`
+	Age    int    `go_deep_copy:"age"`
+	Tags   []string
+	Scores []float64 `go_deep_copy:"scores"`
+	Wait   time.Duration
+	At     *time.Time
+	Addr   *CSVAddress       `go_deep_copy:"addr"`
+	Items  []FormItem        `go_deep_copy:"items"`
+	Labels map[string]string `go_deep_copy:"labels"`
+	Level  string            `go_deep_copy:"level,default=basic"`
+}
+
+// TestBindForm 测试把 url.Values 绑定到结构体
+func TestBindForm(t *testing.T) {
+	values, err := url.ParseQuery("name=Alice&name=ignored&age=30&Tags=a&Tags=b&scores=1.5&scores=2&Wait=1m" +
+		"&At=2024-01-02T03:04:05Z&addr.city=Paris&addr[zip]=75001&items[1].id=8&items[0].id=7&items[0][Qty]=3" +
+		"&labels.env=prod&level=gold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req FormRequest
+	if err := go_deep_copy.BindForm(values, &req); err != nil {
+		t.Fatalf("BindForm failed: %v", err)
+	}
+	if want := newFormRequest(); !reflect.DeepEqual(req, want) {
+		t.Errorf("got %+v, want %+v", req, want)
+	}
+
+	// 没有出现的字段保持原值，default 标签的字段写入默认值，未知的键默认忽略
+	req = FormRequest{Age: 5}
+	if err := go_deep_copy.BindForm(url.Values{"name": {"Bob"}, "Tags[]": {"x"}, "other": {"1"}}, &req); err != nil {
+		t.Fatalf("BindForm failed: %v", err)
+	}
+	if req.Name != "Bob" || req.Age != 5 || req.Level != "basic" || !reflect.DeepEqual(req.Tags, []string{"x"}) || req.Addr != nil {
+		t.Errorf("unexpected request: %+v", req)
+	}
+}
+
+// TestBindFormErrors 测试绑定表单时的错误
+func TestBindFormErrors(t *testing.T) {
+	var req FormRequest
+	err := go_deep_copy.BindForm(url.Values{"name": {"Alice"}, "age": {"x"}}, &req)
+	if !errors.Is(err, strconv.ErrSyntax) || !strings.Contains(err.Error(), "form key age") {
+		t.Errorf("expected a syntax error for age, got %v", err)
+	}
+	if err := go_deep_copy.BindForm(url.Values{"age": {"1"}}, &req); !errors.Is(err, go_deep_copy.ErrMissingField) {
+		t.Errorf("expected ErrMissingField, got %v", err)
+	}
+	if err := go_deep_copy.BindForm(url.Values{}, req); !errors.Is(err, go_deep_copy.ErrInvalidCopyDestination) {
+		t.Errorf("expected ErrInvalidCopyDestination, got %v", err)
+	}
+
+	strict := go_deep_copy.NewCopier(go_deep_copy.WithDisallowUnknownFields())
+	err = strict.BindForm(url.Values{"name": {"Alice"}, "extra": {"1"}, "items[0].nope": {"1"}}, &req)
+	var fieldsErr *go_deep_copy.FieldsError
+	if !errors.As(err, &fieldsErr) || !reflect.DeepEqual(fieldsErr.Unknown, []string{"extra", "items[0].nope"}) {
+		t.Errorf("expected unknown keys, got %v", err)
+	}
+
+	// 过大的下标不会分配内存
+	err = go_deep_copy.BindForm(url.Values{"name": {"Alice"}, "items[99999999].id": {"1"}}, &req)
+	var limitErr *go_deep_copy.LimitError
+	if !errors.Is(err, go_deep_copy.ErrLimitExceeded) || !errors.As(err, &limitErr) || limitErr.Path != "items[99999999].id" {
+		t.Errorf("expected ErrLimitExceeded, got %v", err)
+	}
+	limited := go_deep_copy.NewCopier(go_deep_copy.WithLimits(go_deep_copy.Limits{MaxElements: 2}))
+	if err := limited.BindForm(url.Values{"name": {"Alice"}, "items[2].id": {"1"}}, &req); !errors.Is(err, go_deep_copy.ErrLimitExceeded) {
+		t.Errorf("expected ErrLimitExceeded, got %v", err)
+	}
+}
+
+// TestEncodeForm 测试把结构体编码为 url.Values
+func TestEncodeForm(t *testing.T) {
+	req := newFormRequest()
+	values, err := go_deep_copy.EncodeForm(&req)
+	if err != nil {
+		t.Fatalf("EncodeForm failed: %v", err)
+	}
+	want := url.Values{
+		"name":         {"Alice"},
+		"age":          {"30"},
+		"Tags":         {"a", "b"},
+		"scores":       {"1.5", "2"},
+		"Wait":         {"1m0s"},
+		"At":           {"2024-01-02T03:04:05Z"},
+		"addr.city":    {"Paris"},
+		"addr.zip":     {"75001"},
+		"items[0].id":  {"7"},
+		"items[0].Qty": {"3"},
+		"items[1].id":  {"8"},
+		"labels.env":   {"prod"},
+		"level":        {"gold"},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("got %v, want %v", values, want)
+	}
+
+	// 编码的结果可以绑定回相同的结构体
+	var back FormRequest
+	if err := go_deep_copy.BindForm(values, &back); err != nil || !reflect.DeepEqual(back, req) {
+		t.Errorf("round trip failed: %v, %+v", err, back)
+	}
+
+	if _, err := go_deep_copy.EncodeForm((*FormRequest)(nil)); !errors.Is(err, go_deep_copy.ErrInvalidCopyFrom) {
+		t.Errorf("expected ErrInvalidCopyFrom, got %v", err)
+	}
+	if _, err := go_deep_copy.EncodeForm(struct{ Events chan int }{}); !errors.Is(err, go_deep_copy.ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithRedaction(go_deep_copy.RedactDrop))
+	values, err = copier.EncodeForm(LoginLog{User: "alice", Auth: &Credentials{Token: "t"}})
+	if err != nil || values.Encode() != "PIN=0&Password=&User=alice&key=" {
+		t.Errorf("unexpected values: %v, %v", err, values)
+	}
+}
+
+// TestFormDottedTags 测试标签名本身含点号的字段
+func TestFormDottedTags(t *testing.T) {
+	zip := 75001
+	values, err := go_deep_copy.EncodeForm(CSVOrderRow{ID: 1, City: "Paris", Zip: &zip, Tier: "gold"})
+	want := url.Values{"id": {"1"}, "Customer.City": {"Paris"}, "Customer.Zip": {"75001"}, "Customer.Tier": {"gold"}}
+	if err != nil || !reflect.DeepEqual(values, want) {
+		t.Errorf("got %v, %v, want %v", values, err, want)
+	}
+
+	var row CSVOrderRow
+	if err := go_deep_copy.BindForm(url.Values{"id": {"1"}, "Customer.City": {"Paris"}, "Customer[Zip]": {"75001"}}, &row); err != nil {
+		t.Fatalf("BindForm failed: %v", err)
+	}
+	if want := (CSVOrderRow{ID: 1, City: "Paris", Zip: &zip, Tier: "basic"}); !reflect.DeepEqual(row, want) {
+		t.Errorf("got %+v, want %+v", row, want)
+	}
+	if err := go_deep_copy.BindForm(url.Values{"id": {"1"}}, &row); !errors.Is(err, go_deep_copy.ErrMissingField) {
+		t.Errorf("expected ErrMissingField, got %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package go_deep_copy_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+type Status int
+
+const StatusActive Status = 2
+
+// TestInterfaceModes 测试拷贝到 interface{} 时的动态类型
+func TestInterfaceModes(t *testing.T) {
+	t.Run("clone preserves dynamic types", func(t *testing.T) {
+		source := map[string]interface{}{
+			"int32":  int32(7),
+			"status": StatusActive,
+			"float":  float32(1.5),
+			"nested": map[string]interface{}{"uint8": uint8(3)},
+			"list":   []interface{}{int16(1), "a"},
+		}
+
+		target, err := go_deep_copy.Clone(source)
+		if err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+		if !reflect.DeepEqual(target, source) {
+			t.Errorf("Clone changed values: got %#v, want %#v", target, source)
+		}
+		if _, ok := target["status"].(Status); !ok {
+			t.Errorf("named type lost: got %T", target["status"])
+		}
+
+		source["nested"].(map[string]interface{})["uint8"] = uint8(4)
+		if target["nested"].(map[string]interface{})["uint8"] != uint8(3) {
+			t.Error("nested map was not deep copied")
+		}
+	})
+
+	t.Run("deep copy widens numbers by default", func(t *testing.T) {
+		source := map[string]interface{}{"int32": int32(7)}
+		var target map[string]interface{}
+		err := go_deep_copy.DeepCopy(&source, &target)
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if target["int32"] != int64(7) {
+			t.Errorf("int32 should be widened to int64: got %T", target["int32"])
+		}
+	})
+
+	t.Run("json mode", func(t *testing.T) {
+		type Address struct {
+			City string
+		}
+		type Person struct {
+			Name     string
+			Age      int32
+			Status   Status
+			Tags     []string
+			Address  *Address
+			Avatar   []byte
+			Scores   map[int]uint
+			Birthday time.Time
+		}
+
+		birthday := time.Date(1990, 1, 2, 3, 4, 5, 0, time.UTC)
+		source := Person{
+			Name:     "张三",
+			Age:      25,
+			Status:   StatusActive,
+			Tags:     []string{"a", "b"},
+			Address:  &Address{City: "北京"},
+			Avatar:   []byte("hi"),
+			Scores:   map[int]uint{1: 90},
+			Birthday: birthday,
+		}
+
+		copier := go_deep_copy.NewCopier(go_deep_copy.WithInterfaceMode(go_deep_copy.InterfaceJSON))
+		var target map[string]interface{}
+		err := copier.DeepCopy(&source, &target)
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+
+		want := map[string]interface{}{
+			"Name":     "张三",
+			"Age":      float64(25),
+			"Status":   float64(2),
+			"Tags":     []interface{}{"a", "b"},
+			"Address":  map[string]interface{}{"City": "北京"},
+			"Avatar":   "aGk=",
+			"Scores":   map[string]interface{}{"1": float64(90)},
+			"Birthday": "1990-01-02T03:04:05Z",
+		}
+		if !reflect.DeepEqual(target, want) {
+			t.Errorf("json mode mismatch:\n got %#v\nwant %#v", target, want)
+		}
+	})
+}
+
+type Shape interface {
+	Area() float64
+}
+
+type Circle struct {
+	R float64
+}
+
+func (c Circle) Area() float64 { return 3 * c.R * c.R }
+
+type Square struct {
+	Side float64
+}
+
+func (s *Square) Area() float64 { return s.Side * s.Side }
+
+func (s Status) String() string { return "status" }
+
+// TestNonEmptyInterface 测试目标为非空接口（error、fmt.Stringer、自定义接口）
+func TestNonEmptyInterface(t *testing.T) {
+	type Source struct {
+		Shape  Shape
+		Any    interface{}
+		Square Square
+		Err    error
+		Status Status
+	}
+	type Target struct {
+		Shape  Shape
+		Any    Shape
+		Square Shape
+		Err    error
+		Status fmt.Stringer
+	}
+
+	source := Source{
+		Shape:  &Square{Side: 2},
+		Any:    Circle{R: 1},
+		Square: Square{Side: 3},
+		Err:    errors.New("boom"),
+		Status: StatusActive,
+	}
+	var target Target
+	err := go_deep_copy.DeepCopy(&source, &target)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	if sq, ok := target.Shape.(*Square); !ok || sq.Side != 2 || sq == source.Shape {
+		t.Errorf("Shape not deep copied: got %#v", target.Shape)
+	}
+	if target.Any != (Circle{R: 1}) {
+		t.Errorf("Any not copied into Shape: got %#v", target.Any)
+	}
+	if sq, ok := target.Square.(*Square); !ok || sq.Side != 3 {
+		t.Errorf("Square should be stored as *Square: got %#v", target.Square)
+	}
+	if target.Err == nil || target.Err.Error() != "boom" {
+		t.Errorf("Err not copied: got %v", target.Err)
+	}
+	if s, ok := target.Status.(Status); !ok || s != StatusActive {
+		t.Errorf("Status not copied into fmt.Stringer: got %#v", target.Status)
+	}
+
+	t.Run("value does not implement interface", func(t *testing.T) {
+		source := Source{Any: 1}
+		var target Target
+		err := go_deep_copy.DeepCopy(&source, &target)
+		var ifaceErr *go_deep_copy.InterfaceError
+		if !errors.As(err, &ifaceErr) {
+			t.Fatalf("expected *InterfaceError, got %v", err)
+		}
+		if ifaceErr.Interface != reflect.TypeOf((*Shape)(nil)).Elem() {
+			t.Errorf("unexpected interface in error: %v", ifaceErr.Interface)
+		}
+	})
+}
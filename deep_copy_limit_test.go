@@ -0,0 +1,167 @@
+package go_deep_copy_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+// nestedMap 返回嵌套 depth 层的 map[string]interface{}，模拟解码得到的恶意输入
+func nestedMap(depth int) map[string]interface{} {
+	m := map[string]interface{}{"leaf": "x"}
+	for i := 1; i < depth; i++ {
+		m = map[string]interface{}{"next": m}
+	}
+	return m
+}
+
+func limitError(t *testing.T, err error) *go_deep_copy.LimitError {
+	t.Helper()
+	if !errors.Is(err, go_deep_copy.ErrLimitExceeded) {
+		t.Fatalf("expected ErrLimitExceeded, got %v", err)
+	}
+	var limitErr *go_deep_copy.LimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("expected *LimitError, got %T", err)
+	}
+	return limitErr
+}
+
+// TestLimitsDepth 测试嵌套深度限制
+func TestLimitsDepth(t *testing.T) {
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithLimits(go_deep_copy.Limits{MaxDepth: 3}))
+
+	source := nestedMap(3)
+	var target map[string]interface{}
+	if err := copier.DeepCopy(&source, &target); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if !reflect.DeepEqual(target, source) {
+		t.Errorf("got %v, want %v", target, source)
+	}
+
+	source = nestedMap(4)
+	target = nil
+	limitErr := limitError(t, copier.DeepCopy(&source, &target))
+	if limitErr.Limit != "depth" || limitErr.Max != 3 || limitErr.Path != "next.next.next" {
+		t.Errorf("unexpected error: %+v", limitErr)
+	}
+
+	// 默认不限制
+	if err := go_deep_copy.DeepCopy(&source, &target); err != nil {
+		t.Errorf("Copy failed: %v", err)
+	}
+}
+
+// TestLimitsElements 测试单次拷贝的元素总数限制，多个容器的元素累计计算
+func TestLimitsElements(t *testing.T) {
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithLimits(go_deep_copy.Limits{MaxElements: 4}))
+
+	source := newMaskOrder()
+	var target Order
+	if err := copier.DeepCopy(&source, &target); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	source.Tags["z"] = "w"
+	limitErr := limitError(t, copier.DeepCopy(&source, &target))
+	if limitErr.Limit != "elements" || limitErr.Path != "Tags" {
+		t.Errorf("unexpected error: %+v", limitErr)
+	}
+
+	// 每次拷贝重新计数
+	source = newMaskOrder()
+	for i := 0; i < 3; i++ {
+		if err := copier.DeepCopy(&source, &target); err != nil {
+			t.Fatalf("Copy %d failed: %v", i, err)
+		}
+	}
+
+	hostile := []interface{}{1, []interface{}{2, 3}, []interface{}{4}}
+	var out []interface{}
+	limitErr = limitError(t, copier.DeepCopy(&hostile, &out))
+	if limitErr.Path != "[1]" {
+		t.Errorf("unexpected path: %q", limitErr.Path)
+	}
+}
+
+// TestLimitsStringLen 测试 string 与 []byte 的长度限制
+func TestLimitsStringLen(t *testing.T) {
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithLimits(go_deep_copy.Limits{MaxStringLen: 8}))
+
+	source := newMaskOrder()
+	source.Items[1].SKU = strings.Repeat("a", 9)
+	var target Order
+	limitErr := limitError(t, copier.DeepCopy(&source, &target))
+	if limitErr.Limit != "string length" || limitErr.Path != "Items[1].SKU" {
+		t.Errorf("unexpected error: %+v", limitErr)
+	}
+
+	input := map[string]interface{}{"name": "Alice", "key": []byte(strings.Repeat("k", 9))}
+	var out map[string]interface{}
+	limitErr = limitError(t, copier.DeepCopy(&input, &out))
+	if limitErr.Path != "key" {
+		t.Errorf("unexpected path: %q", limitErr.Path)
+	}
+
+	var form SignupForm
+	input = map[string]interface{}{"email": strings.Repeat("e", 9)}
+	limitErr = limitError(t, copier.DeepCopy(&input, &form))
+	if limitErr.Path != "email" {
+		t.Errorf("unexpected path: %q", limitErr.Path)
+	}
+}
+
+// TestLimitsInterfaces 测试装入 interface{} 的值同样受限制，覆盖解码 JSON 得到的常见结构
+func TestLimitsInterfaces(t *testing.T) {
+	long := strings.Repeat("x", 1000)
+	for _, mode := range []go_deep_copy.InterfaceMode{go_deep_copy.InterfaceWiden, go_deep_copy.InterfacePreserve, go_deep_copy.InterfaceJSON} {
+		copier := go_deep_copy.NewCopier(
+			go_deep_copy.WithLimits(go_deep_copy.Limits{MaxStringLen: 10}),
+			go_deep_copy.WithInterfaceMode(mode),
+		)
+		m := map[string]interface{}{"ok": "x", "name": long}
+		var mOut map[string]interface{}
+		if limitErr := limitError(t, copier.DeepCopy(&m, &mOut)); limitErr.Path != "name" {
+			t.Errorf("mode %d: unexpected path: %q", mode, limitErr.Path)
+		}
+		s := []interface{}{"x", []interface{}{long}}
+		var sOut []interface{}
+		if limitErr := limitError(t, copier.DeepCopy(&s, &sOut)); limitErr.Path != "[1][0]" {
+			t.Errorf("mode %d: unexpected path: %q", mode, limitErr.Path)
+		}
+		b := []interface{}{[]byte(long)}
+		if limitErr := limitError(t, copier.DeepCopy(&b, &sOut)); limitErr.Path != "[0]" {
+			t.Errorf("mode %d: unexpected path: %q", mode, limitErr.Path)
+		}
+		field := struct{ Any interface{} }{Any: long}
+		var fieldOut struct{ Any interface{} }
+		if limitErr := limitError(t, copier.DeepCopy(&field, &fieldOut)); limitErr.Path != "Any" {
+			t.Errorf("mode %d: unexpected path: %q", mode, limitErr.Path)
+		}
+	}
+
+	// 接口中的容器计入元素总数与深度
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithLimits(go_deep_copy.Limits{MaxElements: 50}))
+	items := make([]interface{}, 20)
+	for i := range items {
+		items[i] = []interface{}{1, 2, 3}
+	}
+	var out []interface{}
+	if limitErr := limitError(t, copier.DeepCopy(&items, &out)); limitErr.Limit != "elements" {
+		t.Errorf("unexpected error: %+v", limitErr)
+	}
+
+	copier = go_deep_copy.NewCopier(go_deep_copy.WithLimits(go_deep_copy.Limits{MaxDepth: 3}))
+	var deep interface{} = "x"
+	for i := 0; i < 5; i++ {
+		deep = []interface{}{deep}
+	}
+	var deepOut interface{}
+	if limitErr := limitError(t, copier.DeepCopy(&deep, &deepOut)); limitErr.Limit != "depth" || limitErr.Path != "[0][0][0]" {
+		t.Errorf("unexpected error: %+v", limitErr)
+	}
+}
@@ -0,0 +1,94 @@
+package go_deep_copy_test
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+type SDKAccount struct {
+	FullName string
+	Age      string
+	Secret   string
+	City     string
+	Score    int
+}
+
+type AccountDTO struct {
+	Name    string
+	Age     int
+	Secret  string
+	Score   int
+	Summary string
+	Address Address
+}
+
+// TestMapping 测试不依赖标签的字段映射配置
+func TestMapping(t *testing.T) {
+	mapping := go_deep_copy.NewMapping[SDKAccount, AccountDTO]().
+		Field("Dst.Name", "Src.FullName").
+		Ignore("Dst.Secret").
+		Convert("Dst.Age", strconv.Atoi).
+		Convert("AccountDTO.Summary", func(a SDKAccount) string {
+			return a.FullName + "@" + a.City
+		}).
+		Field("Address.City", "City")
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithMapping(mapping))
+	if err := copier.Precompile(go_deep_copy.PairOf[SDKAccount, AccountDTO]()); err != nil {
+		t.Fatalf("Precompile failed: %v", err)
+	}
+
+	source := SDKAccount{FullName: "Alice", Age: "30", Secret: "s", City: "Paris", Score: 9}
+	target := AccountDTO{Secret: "keep"}
+	if err := copier.DeepCopy(&source, &target); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	want := AccountDTO{Name: "Alice", Age: 30, Secret: "keep", Score: 9, Summary: "Alice@Paris", Address: Address{City: "Paris"}}
+	if target != want {
+		t.Errorf("got %+v, want %+v", target, want)
+	}
+
+	source.Age = "x"
+	if err := copier.DeepCopy(&source, &target); !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("expected convert error, got %v", err)
+	}
+
+	report := copier.Check(reflect.TypeOf(SDKAccount{}), reflect.TypeOf(AccountDTO{}))
+	if len(report.Unfilled) != 0 || len(report.Unmatched) != 1 || report.Unmatched[0] != "Secret" {
+		t.Errorf("unexpected report: %s", report)
+	}
+
+	// 未登记映射的 Copier 按名称匹配
+	source.Age = "30"
+	target = AccountDTO{}
+	if err := go_deep_copy.DeepCopy(&source, &target); err != nil || target.Name != "" || target.Secret != "s" {
+		t.Errorf("default copier should ignore the mapping: %v, %+v", err, target)
+	}
+}
+
+// TestInvalidMapping 测试无效映射在构建计划时报告
+func TestInvalidMapping(t *testing.T) {
+	mappings := map[string]*go_deep_copy.Mapping[SDKAccount, AccountDTO]{
+		"unknown destination": go_deep_copy.NewMapping[SDKAccount, AccountDTO]().Field("Nope", "FullName"),
+		"unknown source":      go_deep_copy.NewMapping[SDKAccount, AccountDTO]().Field("Name", "Nope"),
+		"nested ignore":       go_deep_copy.NewMapping[SDKAccount, AccountDTO]().Ignore("Address.City"),
+		"bad convert":         go_deep_copy.NewMapping[SDKAccount, AccountDTO]().Convert("Age", func(i int) int { return i }),
+		"not a func":          go_deep_copy.NewMapping[SDKAccount, AccountDTO]().Convert("Age", 1),
+	}
+	for name, m := range mappings {
+		t.Run(name, func(t *testing.T) {
+			copier := go_deep_copy.NewCopier(go_deep_copy.WithMapping(m))
+			err := copier.Precompile(go_deep_copy.PairOf[SDKAccount, AccountDTO]())
+			if !errors.Is(err, go_deep_copy.ErrInvalidMapping) {
+				t.Errorf("expected ErrInvalidMapping, got %v", err)
+			}
+			var target AccountDTO
+			if err := copier.DeepCopy(&SDKAccount{}, &target); !errors.Is(err, go_deep_copy.ErrInvalidMapping) {
+				t.Errorf("expected ErrInvalidMapping, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package go_deep_copy_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+// TestOnlyPaths 测试只拷贝字段掩码选中的路径
+func TestOnlyPaths(t *testing.T) {
+	t.Run("struct to struct", func(t *testing.T) {
+		target := Order{
+			Customer: &User{Name: "Bob", Age: 40},
+			Items:    []OrderItem{{SKU: "old", Count: 9}},
+			Tags:     map[string]string{"keep": "me"},
+		}
+		customer := target.Customer
+		source := newMaskOrder()
+		err := go_deep_copy.DeepCopyWithOptions(&source, &target, go_deep_copy.OnlyPaths("ID", "Customer.Name", "Items[*].SKU", "Tags.k"))
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		want := Order{
+			ID:       7,
+			Customer: &User{Name: "Alice", Age: 40},
+			Items:    []OrderItem{{SKU: "a", Count: 9}, {SKU: "b"}},
+			Tags:     map[string]string{"keep": "me", "k": "v"},
+		}
+		if !reflect.DeepEqual(target, want) {
+			t.Errorf("got %+v, want %+v", target, want)
+		}
+		if target.Customer != customer {
+			t.Error("existing destination pointer should be updated in place")
+		}
+		if source.Items[0].Count != 1 {
+			t.Error("source should not be modified")
+		}
+	})
+
+	t.Run("nil source pointer", func(t *testing.T) {
+		// 只选中指针之下的路径时，目标指针保留，未选中的字段不变
+		target := Order{ID: 1, Customer: &User{Name: "Bob", Age: 40}}
+		customer := target.Customer
+		err := go_deep_copy.DeepCopyWithOptions(&Order{ID: 7}, &target, go_deep_copy.OnlyPaths("Customer.Name"))
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if want := (Order{ID: 1, Customer: &User{Age: 40}}); !reflect.DeepEqual(target, want) || target.Customer != customer {
+			t.Errorf("got %+v, want %+v", target, want)
+		}
+
+		empty := Order{ID: 1}
+		if err := go_deep_copy.DeepCopyWithOptions(&Order{ID: 7}, &empty, go_deep_copy.OnlyPaths("Customer.Name")); err != nil || empty.Customer != nil {
+			t.Errorf("nil destination pointer should stay nil: %v, %+v", err, empty.Customer)
+		}
+
+		// 选中指针本身时目标指针置为 nil
+		if err := go_deep_copy.DeepCopyWithOptions(&Order{ID: 7}, &target, go_deep_copy.OnlyPaths("Customer")); err != nil || target.Customer != nil {
+			t.Errorf("selected pointer should be copied as nil: %v, %+v", err, target.Customer)
+		}
+	})
+
+	t.Run("struct to map", func(t *testing.T) {
+		source := User{Name: "Alice", Age: 30, Class: &Class{Name: "A"}}
+		var target map[string]interface{}
+		err := go_deep_copy.DeepCopyWithOptions(&source, &target, go_deep_copy.OnlyPaths("Name", "Class"))
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if len(target) != 2 || target["Name"] != "Alice" || target["Class"] == nil {
+			t.Errorf("unexpected target: %v", target)
+		}
+
+		// interface{} 的值在拷贝前无法确定结构，不能继续深入
+		err = go_deep_copy.DeepCopyWithOptions(&source, &target, go_deep_copy.OnlyPaths("Class.Name"))
+		if !errors.Is(err, go_deep_copy.ErrInvalidMask) {
+			t.Errorf("expected ErrInvalidMask, got %v", err)
+		}
+	})
+
+	t.Run("map to struct", func(t *testing.T) {
+		// 未选中的必填字段不参与校验
+		source := map[string]interface{}{"name": "Alice", "age": 20}
+		target := SignupForm{Email: "a@b.c", Age: 1}
+		err := go_deep_copy.DeepCopyWithOptions(&source, &target, go_deep_copy.OnlyPaths("name"))
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if *target.Name != "Alice" || target.Email != "a@b.c" || target.Age != 1 {
+			t.Errorf("unexpected target: %+v", target)
+		}
+	})
+}
+
+// TestExceptPaths 测试排除字段掩码中的路径
+func TestExceptPaths(t *testing.T) {
+	source := newMaskOrder()
+	target := Order{ID: 1, Items: []OrderItem{{SKU: "old", Count: 9}}}
+	err := go_deep_copy.DeepCopyWithOptions(&source, &target, go_deep_copy.ExceptPaths("ID", "Items[*].Count", "Tags.x"))
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	want := Order{
+		ID:       1,
+		Customer: &User{Name: "Alice", Age: 30},
+		Items:    []OrderItem{{SKU: "a", Count: 9}, {SKU: "b"}},
+		Tags:     map[string]string{"k": "v"},
+	}
+	if !reflect.DeepEqual(target, want) {
+		t.Errorf("got %+v, want %+v", target, want)
+	}
+
+	// 源指针为 nil 时只把未排除的字段置零
+	partial := Order{Customer: &User{Name: "Bob", Age: 40}}
+	if err := go_deep_copy.DeepCopyWithOptions(&Order{}, &partial, go_deep_copy.ExceptPaths("Customer.Age")); err != nil || !reflect.DeepEqual(partial.Customer, &User{Age: 40}) {
+		t.Errorf("unexpected customer: %v, %+v", err, partial.Customer)
+	}
+
+	// 没有掩码时与 DeepCopy 相同
+	var full Order
+	if err := go_deep_copy.DeepCopyWithOptions(&source, &full); err != nil || !reflect.DeepEqual(full, source) {
+		t.Errorf("copy without options mismatch: %v, %+v", err, full)
+	}
+
+	// 被排除的字段不会构建转换函数，不支持的转换也不会报错
+	var bad BadOrderDTO
+	if err := go_deep_copy.DeepCopy(&source, &bad); !errors.Is(err, go_deep_copy.ErrNotSupported) {
+		t.Fatalf("expected ErrNotSupported, got %v", err)
+	}
+	if err := go_deep_copy.DeepCopyWithOptions(&source, &bad, go_deep_copy.ExceptPaths("ID", "Items")); err != nil {
+		t.Errorf("excluded fields should not be visited: %v", err)
+	}
+}
+
+// TestInvalidMask 测试无效的字段掩码
+func TestInvalidMask(t *testing.T) {
+	cases := map[string][]go_deep_copy.CopyOption{
+		"unknown field":   {go_deep_copy.OnlyPaths("Nope")},
+		"inside string":   {go_deep_copy.OnlyPaths("Customer.Name.X")},
+		"slice index":     {go_deep_copy.OnlyPaths("Items[0].SKU")},
+		"slice by name":   {go_deep_copy.ExceptPaths("Items.SKU")},
+		"empty segment":   {go_deep_copy.OnlyPaths("Customer..Name")},
+		"only and except": {go_deep_copy.OnlyPaths("ID"), go_deep_copy.ExceptPaths("Tags")},
+	}
+	for name, opts := range cases {
+		t.Run(name, func(t *testing.T) {
+			source := newMaskOrder()
+			var target Order
+			err := go_deep_copy.DeepCopyWithOptions(&source, &target, opts...)
+			if !errors.Is(err, go_deep_copy.ErrInvalidMask) {
+				t.Errorf("expected ErrInvalidMask, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package go_deep_copy_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+var errNoLevel = errors.New("no level")
+
+type Account struct {
+	Name  string
+	Level int32
+	Tags  []string
+}
+
+func (a *Account) Labels() []string {
+	return a.Tags
+}
+
+func (a Account) Rank() (string, error) {
+	if a.Level == 0 {
+		return "", errNoLevel
+	}
+	return "L" + string(rune('0'+a.Level)), nil
+}
+
+type Member struct {
+	Name   string
+	Rank   string
+	Labels []string
+	level  int64
+}
+
+func (m *Member) SetLevel(level int64) error {
+	if level < 0 {
+		return errNoLevel
+	}
+	m.level = level
+	return nil
+}
+
+// TestMethodMapping 测试通过取值方法与赋值方法映射字段
+func TestMethodMapping(t *testing.T) {
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithMethods())
+
+	t.Run("getter and setter", func(t *testing.T) {
+		var employee Employee
+		err := copier.DeepCopy(&User{Name: "Alice", Age: 21, Role: "Admin"}, &employee)
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if employee.DoubleAge != 42 || employee.SuperRule != "Super Admin" || employee.Name != "Alice" {
+			t.Errorf("methods not applied: %+v", employee)
+		}
+
+		employee = Employee{}
+		if err := go_deep_copy.DeepCopy(&User{Age: 21, Role: "Admin"}, &employee); err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if employee.DoubleAge != 0 || employee.SuperRule != "" {
+			t.Errorf("methods should be opt-in: %+v", employee)
+		}
+	})
+
+	t.Run("converted and copied values", func(t *testing.T) {
+		source := Account{Name: "a", Level: 3, Tags: []string{"x"}}
+		var member Member
+		if err := copier.DeepCopy(&source, &member); err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if member.Rank != "L3" || member.level != 3 || !reflect.DeepEqual(member.Labels, []string{"x"}) {
+			t.Errorf("methods not applied: %+v", member)
+		}
+		member.Labels[0] = "y"
+		if source.Tags[0] != "x" {
+			t.Error("getter results should be deep copied")
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var member Member
+		if err := copier.DeepCopy(&Account{Name: "a"}, &member); !errors.Is(err, errNoLevel) {
+			t.Errorf("expected getter error, got %v", err)
+		}
+		if err := copier.DeepCopy(&Account{Level: -1}, &member); !errors.Is(err, errNoLevel) {
+			t.Errorf("expected setter error, got %v", err)
+		}
+	})
+
+	t.Run("check", func(t *testing.T) {
+		report := copier.Check(reflect.TypeOf(Account{}), reflect.TypeOf(Member{}))
+		// 方法内部读写的字段无法静态得知
+		if want := []string{"Name", "Rank", "Labels", "Level()"}; !reflect.DeepEqual(report.Filled, want) {
+			t.Errorf("Filled mismatch: got %v, want %v", report.Filled, want)
+		}
+		if want := []string{"Tags"}; !reflect.DeepEqual(report.Unmatched, want) {
+			t.Errorf("Unmatched mismatch: got %v, want %v", report.Unmatched, want)
+		}
+	})
+}
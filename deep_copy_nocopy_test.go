@@ -0,0 +1,160 @@
+package go_deep_copy_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+type noCopy struct{}
+
+func (*noCopy) Lock()   {}
+func (*noCopy) Unlock() {}
+
+type Conn struct {
+	noCopy noCopy
+	FD     int
+}
+
+type Service struct {
+	sync.Mutex
+	Name   string
+	Mu     sync.RWMutex
+	Wg     sync.WaitGroup
+	Once   *sync.Once
+	Config atomic.Value
+	Conn   Conn
+}
+
+// TestNoCopyTypes 测试锁、原子类型等不可拷贝类型
+func TestNoCopyTypes(t *testing.T) {
+	t.Run("locks are zeroed", func(t *testing.T) {
+		source := &Service{Name: "svc", Once: &sync.Once{}, Conn: Conn{FD: 3}}
+		source.Lock()
+		source.Mu.RLock()
+		source.Wg.Add(1)
+		source.Once.Do(func() {})
+		source.Config.Store("v1")
+
+		var target Service
+		err := go_deep_copy.DeepCopy(source, &target)
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if target.Name != "svc" {
+			t.Errorf("Name not copied: got %s", target.Name)
+		}
+		if !target.TryLock() {
+			t.Error("embedded Mutex should be unlocked in the copy")
+		}
+		if !target.Mu.TryLock() {
+			t.Error("RWMutex should be unlocked in the copy")
+		}
+		target.Wg.Wait()
+		if target.Once == nil || target.Once == source.Once {
+			t.Fatal("Once pointer should point to a new value")
+		}
+		called := false
+		target.Once.Do(func() { called = true })
+		if !called {
+			t.Error("Once should be reset in the copy")
+		}
+		if target.Conn.FD != 0 {
+			t.Errorf("type with noCopy marker should be zeroed: got FD %d", target.Conn.FD)
+		}
+	})
+
+	t.Run("atomic value is loaded", func(t *testing.T) {
+		type Holder struct {
+			Value atomic.Value
+		}
+
+		hosts := []string{"a", "b"}
+		source := &Holder{}
+		source.Value.Store(hosts)
+
+		var target Holder
+		err := go_deep_copy.DeepCopy(source, &target)
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		got, ok := target.Value.Load().([]string)
+		if !ok {
+			t.Fatalf("atomic.Value not copied: got %T", target.Value.Load())
+		}
+		hosts[0] = "modified"
+		if len(got) != 2 || got[0] != "a" {
+			t.Errorf("atomic.Value should hold a deep copy: got %v", got)
+		}
+	})
+
+	t.Run("atomic value into populated destination", func(t *testing.T) {
+		type Holder struct {
+			Value atomic.Value
+		}
+
+		source := &Holder{}
+		source.Value.Store([]string{"a"})
+		var target Holder
+		target.Value.Store(42)
+		if err := go_deep_copy.DeepCopy(source, &target); err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if got, ok := target.Value.Load().([]string); !ok || len(got) != 1 || got[0] != "a" {
+			t.Errorf("atomic.Value not replaced: got %#v", target.Value.Load())
+		}
+
+		// 源为空时目标同样为空
+		if err := go_deep_copy.DeepCopy(&Holder{}, &target); err != nil || target.Value.Load() != nil {
+			t.Errorf("empty atomic.Value should clear the destination: %v, %#v", err, target.Value.Load())
+		}
+	})
+
+	t.Run("atomic value is checked against limits", func(t *testing.T) {
+		type Holder struct {
+			Value atomic.Value
+		}
+
+		source := &Holder{}
+		source.Value.Store(strings.Repeat("x", 100))
+		copier := go_deep_copy.NewCopier(go_deep_copy.WithLimits(go_deep_copy.Limits{MaxStringLen: 10}))
+		var target Holder
+		if err := copier.DeepCopy(source, &target); !errors.Is(err, go_deep_copy.ErrLimitExceeded) {
+			t.Errorf("expected ErrLimitExceeded, got %v", err)
+		}
+	})
+
+	t.Run("atomic value to plain field", func(t *testing.T) {
+		type Source struct {
+			Version atomic.Value
+		}
+		type Target struct {
+			Version string
+		}
+
+		source := &Source{}
+		source.Version.Store("1.0.0")
+		var target Target
+		err := go_deep_copy.DeepCopy(source, &target)
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if target.Version != "1.0.0" {
+			t.Errorf("Version not copied: got %s", target.Version)
+		}
+	})
+
+	t.Run("error policy", func(t *testing.T) {
+		copier := go_deep_copy.NewCopier(go_deep_copy.WithNoCopyPolicy(go_deep_copy.NoCopyError))
+		source := &Service{Name: "svc"}
+		var target Service
+		err := copier.DeepCopy(source, &target)
+		if !errors.Is(err, go_deep_copy.ErrNoCopy) {
+			t.Errorf("expected ErrNoCopy, got %v", err)
+		}
+	})
+}
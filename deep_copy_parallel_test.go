@@ -0,0 +1,72 @@
+package go_deep_copy_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+var errBadSKU = errors.New("bad sku")
+
+// TestParallelCopy 测试并行拷贝较长的切片
+func TestParallelCopy(t *testing.T) {
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithParallel(4, 100))
+
+	source := newItems(5000)
+	var target []OrderItem
+	if err := copier.DeepCopy(&source, &target); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if !reflect.DeepEqual(target, source) {
+		t.Error("parallel copy mismatch")
+	}
+
+	// 嵌套的切片共用同一组 goroutine
+	nested := [][]OrderItem{newItems(300), nil, newItems(50), newItems(1000)}
+	var nestedTarget [][]OrderItem
+	if err := copier.DeepCopy(&nested, &nestedTarget); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if !reflect.DeepEqual(nestedTarget, nested) {
+		t.Error("nested parallel copy mismatch")
+	}
+	nestedTarget[0][0].SKU = "changed"
+	if nested[0][0].SKU != "0" {
+		t.Error("source should not be modified")
+	}
+
+	// 低于阈值时顺序拷贝
+	var short []OrderItem
+	if err := copier.DeepCopy(newItems(10), &short); err != nil || len(short) != 10 {
+		t.Errorf("short copy mismatch: %v, %d", err, len(short))
+	}
+}
+
+// TestParallelCopyError 测试并行拷贝出错时返回下标最小的错误
+func TestParallelCopyError(t *testing.T) {
+	mapping := go_deep_copy.NewMapping[OrderItem, OrderItem]().
+		Convert("SKU", func(sku string) (string, error) {
+			switch sku {
+			case "700", "2500", "4999":
+				return "", fmt.Errorf("%s: %w", sku, errBadSKU)
+			}
+			return sku, nil
+		})
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithParallel(8, 100), go_deep_copy.WithMapping(mapping))
+
+	source := newItems(5000)
+	for i := 0; i < 20; i++ {
+		var target []OrderItem
+		err := copier.DeepCopy(&source, &target)
+		if !errors.Is(err, errBadSKU) || !strings.HasSuffix(err.Error(), "700: bad sku") {
+			t.Fatalf("expected the error of index 700, got %v", err)
+		}
+		if target != nil {
+			t.Fatal("destination should not be assigned on error")
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package go_deep_copy_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+type Address struct {
+	City   string
+	Street string
+}
+
+type Customer struct {
+	Name    string
+	Address *Address
+}
+
+type Invoice struct {
+	ID       int
+	Customer *Customer
+}
+
+type InvoiceRow struct {
+	ID           int
+	CustomerName string `go_deep_copy:"Customer.Name"`
+	City         string `go_deep_copy:"Customer.Address.City"`
+}
+
+type Base struct {
+	ID   int
+	Name string
+}
+
+type Article struct {
+	Title string
+	Base
+}
+
+type ArticleRef struct {
+	Title string
+	*Base
+}
+
+type ArticleDTO struct {
+	ID    int
+	Name  string
+	Title string
+}
+
+// TestDottedPaths 测试标签中的点号路径在嵌套结构体与扁平结构体之间映射
+func TestDottedPaths(t *testing.T) {
+	t.Run("nested to flat", func(t *testing.T) {
+		source := Invoice{ID: 1, Customer: &Customer{Name: "Alice", Address: &Address{City: "Paris"}}}
+		var target InvoiceRow
+		if err := go_deep_copy.DeepCopy(&source, &target); err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if want := (InvoiceRow{ID: 1, CustomerName: "Alice", City: "Paris"}); target != want {
+			t.Errorf("got %+v, want %+v", target, want)
+		}
+
+		// 源路径上的指针为 nil 时，目标字段置零
+		source.Customer.Address = nil
+		if err := go_deep_copy.DeepCopy(&source, &target); err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if target.City != "" || target.CustomerName != "Alice" {
+			t.Errorf("nil intermediate should clear the field: %+v", target)
+		}
+		if err := go_deep_copy.DeepCopy(&Invoice{ID: 2}, &target); err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if want := (InvoiceRow{ID: 2}); target != want {
+			t.Errorf("got %+v, want %+v", target, want)
+		}
+	})
+
+	t.Run("flat to nested", func(t *testing.T) {
+		source := InvoiceRow{ID: 1, CustomerName: "Alice", City: "Paris"}
+		var target Invoice
+		if err := go_deep_copy.DeepCopy(&source, &target); err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		want := Invoice{ID: 1, Customer: &Customer{Name: "Alice", Address: &Address{City: "Paris"}}}
+		if !reflect.DeepEqual(target, want) {
+			t.Errorf("got %+v, want %+v", target, want)
+		}
+	})
+
+	t.Run("check", func(t *testing.T) {
+		report := go_deep_copy.Check(reflect.TypeOf(Invoice{}), reflect.TypeOf(InvoiceRow{}))
+		if want := []string{"ID", "CustomerName", "City"}; !reflect.DeepEqual(report.Filled, want) {
+			t.Errorf("Filled mismatch: got %v, want %v", report.Filled, want)
+		}
+		if len(report.Unmatched) != 0 {
+			t.Errorf("Unmatched should be empty: %v", report.Unmatched)
+		}
+	})
+}
+
+// TestEmbeddedFields 测试匿名嵌入结构体展开后的字段拷贝
+func TestEmbeddedFields(t *testing.T) {
+	var dto ArticleDTO
+	if err := go_deep_copy.DeepCopy(&Article{Title: "t", Base: Base{ID: 1, Name: "n"}}, &dto); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if want := (ArticleDTO{ID: 1, Name: "n", Title: "t"}); dto != want {
+		t.Errorf("got %+v, want %+v", dto, want)
+	}
+
+	var ref ArticleRef
+	if err := go_deep_copy.DeepCopy(&dto, &ref); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if ref.Base == nil || ref.ID != 1 || ref.Name != "n" || ref.Title != "t" {
+		t.Errorf("embedded pointer should be allocated: %+v", ref)
+	}
+
+	dto = ArticleDTO{}
+	if err := go_deep_copy.DeepCopy(&ArticleRef{Title: "t"}, &dto); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if want := (ArticleDTO{Title: "t"}); dto != want {
+		t.Errorf("got %+v, want %+v", dto, want)
+	}
+
+	m := map[string]interface{}{}
+	if err := go_deep_copy.DeepCopy(&ArticleRef{Title: "t"}, &m); err != nil || len(m) != 1 {
+		t.Errorf("nil embedded pointer should be skipped: %v, %v", err, m)
+	}
+}
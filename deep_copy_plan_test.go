@@ -0,0 +1,132 @@
+package go_deep_copy_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+type OrderItem struct {
+	SKU   string
+	Count int32
+}
+
+type Order struct {
+	ID       int
+	Customer *User
+	Items    []OrderItem
+	Tags     map[string]string
+}
+
+type OrderItemDTO struct {
+	SKU   string
+	Count int64
+}
+
+type OrderDTO struct {
+	ID       string
+	Customer Employee
+	Items    []*OrderItemDTO
+	Tags     map[string]interface{}
+}
+
+type BadItemDTO struct {
+	SKU   []float64
+	Count int64
+}
+
+type BadOrderDTO struct {
+	ID    chan int
+	Items []BadItemDTO
+	Mu    sync.Mutex
+}
+
+// TestPrecompile 测试预编译转换计划及不支持字段的提前报告
+func TestPrecompile(t *testing.T) {
+	t.Run("warm builds the whole tree", func(t *testing.T) {
+		copier := go_deep_copy.NewCopier()
+		err := copier.Precompile(go_deep_copy.PairOf[Order, OrderDTO]())
+		if err != nil {
+			t.Fatalf("Precompile failed: %v", err)
+		}
+		compiled := copier.CacheStats().Compilations
+
+		source := Order{
+			ID:       7,
+			Customer: &User{Name: "Alice", Age: 30},
+			Items:    []OrderItem{{SKU: "a", Count: 2}},
+			Tags:     map[string]string{"k": "v"},
+		}
+		var target OrderDTO
+		err = copier.DeepCopy(&source, &target)
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if target.ID != "7" || target.Customer.Name != "Alice" || target.Items[0].Count != 2 || target.Tags["k"] != "v" {
+			t.Errorf("copy mismatch: %+v", target)
+		}
+		if stats := copier.CacheStats(); stats.Compilations != compiled {
+			t.Errorf("copy compiled %d plans after Precompile", stats.Compilations-compiled)
+		}
+	})
+
+	t.Run("package level warm", func(t *testing.T) {
+		if err := go_deep_copy.Warm[User, Employee](); err != nil {
+			t.Errorf("Warm failed: %v", err)
+		}
+	})
+
+	t.Run("unsupported fields are reported", func(t *testing.T) {
+		copier := go_deep_copy.NewCopier(go_deep_copy.WithNoCopyPolicy(go_deep_copy.NoCopyError))
+		err := copier.Precompile(
+			go_deep_copy.PairOf[Order, BadOrderDTO](),
+			go_deep_copy.PairOf[BadOrderDTO, BadOrderDTO](),
+		)
+		var errs go_deep_copy.PlanErrors
+		if !errors.As(err, &errs) {
+			t.Fatalf("expected PlanErrors, got %v", err)
+		}
+		want := map[string]error{
+			"ID":           go_deep_copy.ErrNotSupported,
+			"Items[*].SKU": go_deep_copy.ErrNotSupported,
+			"Mu":           go_deep_copy.ErrNoCopy,
+		}
+		found := map[string]bool{}
+		for _, e := range errs {
+			if target, ok := want[e.Path]; !ok || !errors.Is(e, target) {
+				t.Errorf("unexpected error at %q: %v", e.Path, e)
+			}
+			found[e.Path] = true
+		}
+		for path := range want {
+			if !found[path] {
+				t.Errorf("missing error at %q", path)
+			}
+		}
+		if !errors.Is(err, go_deep_copy.ErrNotSupported) {
+			t.Errorf("PlanErrors should unwrap to ErrNotSupported: %v", err)
+		}
+	})
+
+	t.Run("interface implementation", func(t *testing.T) {
+		type Holder struct {
+			Status fmt.Stringer
+		}
+		err := go_deep_copy.Precompile(go_deep_copy.TypePair{
+			From: reflect.TypeOf(struct{ Status int }{}),
+			To:   reflect.TypeOf(Holder{}),
+		})
+		var planErr *go_deep_copy.PlanError
+		if !errors.As(err, &planErr) || planErr.Path != "Status" {
+			t.Fatalf("expected PlanError at Status, got %v", err)
+		}
+		var ifaceErr *go_deep_copy.InterfaceError
+		if !errors.As(err, &ifaceErr) {
+			t.Errorf("expected InterfaceError, got %v", err)
+		}
+	})
+}
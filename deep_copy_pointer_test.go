@@ -0,0 +1,123 @@
+package go_deep_copy_test
+
+import (
+	"testing"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+type Node struct {
+	Name  string
+	Next  *Node
+	Value interface{}
+}
+
+// TestInterfaceHoldingPointer 测试接口中保存指针时拷贝结果仍为指针
+func TestInterfaceHoldingPointer(t *testing.T) {
+	t.Run("clone keeps pointers", func(t *testing.T) {
+		source := []interface{}{&Node{Name: "a"}, Node{Name: "b"}}
+
+		target, err := go_deep_copy.Clone(source)
+		if err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+		node, ok := target[0].(*Node)
+		if !ok {
+			t.Fatalf("*Node changed into %T", target[0])
+		}
+		if node == source[0].(*Node) || node.Name != "a" {
+			t.Errorf("*Node not deep copied: got %+v", node)
+		}
+		if _, ok := target[1].(Node); !ok {
+			t.Errorf("Node changed into %T", target[1])
+		}
+	})
+
+	t.Run("deep copy keeps pointers between interfaces", func(t *testing.T) {
+		source := map[string]interface{}{"node": &Node{Name: "a"}}
+		var target map[string]interface{}
+		err := go_deep_copy.DeepCopy(&source, &target)
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		node, ok := target["node"].(*Node)
+		if !ok {
+			t.Fatalf("*Node changed into %T", target["node"])
+		}
+		node.Name = "modified"
+		if source["node"].(*Node).Name != "a" {
+			t.Error("*Node shares memory with the source")
+		}
+	})
+
+	t.Run("pointer interface pointer chain", func(t *testing.T) {
+		leaf := &Node{Name: "leaf"}
+		var iface interface{} = &leaf
+		source := &Node{
+			Name:  "root",
+			Next:  &Node{Name: "next", Value: &iface},
+			Value: []interface{}{&Node{Value: &Node{Name: "deep"}}},
+		}
+
+		target, err := go_deep_copy.Clone(source)
+		if err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+		if target == source || target.Next == source.Next {
+			t.Fatal("pointers should not be shared")
+		}
+
+		ifacePtr, ok := target.Next.Value.(*interface{})
+		if !ok {
+			t.Fatalf("*interface{} changed into %T", target.Next.Value)
+		}
+		leafPtr, ok := (*ifacePtr).(**Node)
+		if !ok {
+			t.Fatalf("**Node changed into %T", *ifacePtr)
+		}
+		if *leafPtr == leaf || (*leafPtr).Name != "leaf" {
+			t.Errorf("**Node not deep copied: got %+v", *leafPtr)
+		}
+
+		list, ok := target.Value.([]interface{})
+		if !ok || len(list) != 1 {
+			t.Fatalf("[]interface{} not copied: got %#v", target.Value)
+		}
+		deep, ok := list[0].(*Node).Value.(*Node)
+		if !ok || deep.Name != "deep" {
+			t.Errorf("nested *Node not copied: got %#v", list[0].(*Node).Value)
+		}
+	})
+
+	t.Run("deep copy still dereferences pointer fields into interface", func(t *testing.T) {
+		user := User{Class: &Class{Name: "Math"}}
+		var employee Employee
+		err := go_deep_copy.DeepCopy(&user, &employee)
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if _, ok := employee.Class.(Class); !ok {
+			t.Errorf("Class should be stored as value: got %T", employee.Class)
+		}
+
+		// 指针字段与其他字段一样按 InterfaceWiden 规则存入
+		b := int32(2)
+		var doc map[string]interface{}
+		if err := go_deep_copy.DeepCopy(&struct {
+			A int32
+			B *int32
+		}{A: 1, B: &b}, &doc); err != nil || doc["A"] != int64(1) || doc["B"] != int64(2) {
+			t.Errorf("pointer fields should be widened like values: %v, %#v", err, doc)
+		}
+
+		// Clone 保留指针类型
+		preserve := go_deep_copy.NewCopier(go_deep_copy.WithInterfaceMode(go_deep_copy.InterfacePreserve))
+		employee = Employee{}
+		if err := preserve.DeepCopy(&user, &employee); err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if class, ok := employee.Class.(*Class); !ok || class == user.Class || class.Name != "Math" {
+			t.Errorf("InterfacePreserve should store a copy of the pointer: got %#v", employee.Class)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package go_deep_copy_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+type Credentials struct {
+	Token string `go_deep_copy:",sensitive"`
+}
+
+type LoginRequest struct {
+	User     string
+	Password string `go_deep_copy:",sensitive"`
+	PIN      int    `go_deep_copy:",sensitive"`
+	Key      []byte `go_deep_copy:"key,sensitive"`
+	Auth     *Credentials
+}
+
+type LoginLog struct {
+	User     string
+	Password string
+	PIN      int
+	Key      []byte `go_deep_copy:"key"`
+	Auth     *Credentials
+}
+
+// TestRedaction 测试拷贝时按脱敏策略处理敏感字段
+func TestRedaction(t *testing.T) {
+	sha := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	cases := []struct {
+		policy go_deep_copy.RedactionPolicy
+		want   LoginLog
+	}{
+		{go_deep_copy.RedactNone, LoginLog{User: "alice", Password: "secret", PIN: 1234, Key: []byte("k"), Auth: &Credentials{Token: "t"}}},
+		{go_deep_copy.RedactZero, LoginLog{User: "alice", Auth: &Credentials{}}},
+		{go_deep_copy.RedactMask, LoginLog{User: "alice", Password: "***", Key: []byte("***"), Auth: &Credentials{Token: "***"}}},
+		{go_deep_copy.RedactHash, LoginLog{User: "alice", Password: sha("secret"), Key: []byte(sha("k")), Auth: &Credentials{Token: sha("t")}}},
+		{go_deep_copy.RedactDrop, LoginLog{User: "alice", Auth: &Credentials{}}},
+	}
+
+	for _, tc := range cases {
+		copier := go_deep_copy.NewCopier(go_deep_copy.WithRedaction(tc.policy))
+		source := newLoginRequest()
+		var target LoginLog
+		if err := copier.DeepCopy(&source, &target); err != nil {
+			t.Fatalf("policy %d: Copy failed: %v", tc.policy, err)
+		}
+		if !reflect.DeepEqual(target, tc.want) {
+			t.Errorf("policy %d: got %+v %+v, want %+v %+v", tc.policy, target, target.Auth, tc.want, tc.want.Auth)
+		}
+		if source.Password != "secret" || source.Auth.Token != "t" {
+			t.Errorf("policy %d: source should not be modified", tc.policy)
+		}
+	}
+}
+
+// TestRedactionToMap 测试 struct -> map 时的脱敏
+func TestRedactionToMap(t *testing.T) {
+	source := newLoginRequest()
+
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithRedaction(go_deep_copy.RedactMask))
+	var masked map[string]interface{}
+	if err := copier.DeepCopy(&source, &masked); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if masked["Password"] != "***" || masked["PIN"] != "***" || masked["User"] != "alice" {
+		t.Errorf("unexpected map: %v", masked)
+	}
+
+	copier = go_deep_copy.NewCopier(go_deep_copy.WithRedaction(go_deep_copy.RedactDrop))
+	var dropped map[string]interface{}
+	if err := copier.DeepCopy(&source, &dropped); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	for _, key := range []string{"Password", "PIN", "Key"} {
+		if _, ok := dropped[key]; ok {
+			t.Errorf("%s should be dropped: %v", key, dropped)
+		}
+	}
+	if dropped["User"] != "alice" {
+		t.Errorf("unexpected map: %v", dropped)
+	}
+
+	var masks map[string]interface{}
+	err := copier.DeepCopyWithOptions(&source, &masks, go_deep_copy.OnlyPaths("User", "Password"))
+	if err != nil || len(masks) != 1 || masks["User"] != "alice" {
+		t.Errorf("field masks should redact as well: %v, %v", err, masks)
+	}
+}
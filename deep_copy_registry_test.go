@@ -0,0 +1,191 @@
+package go_deep_copy_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+type Animal interface {
+	Sound() string
+}
+
+type Dog struct {
+	Name string
+}
+
+func (d Dog) Sound() string { return "woof" }
+
+type Cat struct {
+	Name  string
+	Lives int
+}
+
+func (c *Cat) Sound() string { return "meow" }
+
+type Zoo struct {
+	Keeper  Animal
+	Animals []Animal
+}
+
+func init() {
+	go_deep_copy.RegisterImplementations[Animal]("kind", map[string]Animal{
+		"dog": Dog{},
+		"cat": &Cat{},
+	})
+}
+
+// TestPolymorphicInterface 测试按判别字段选择接口的具体类型
+func TestPolymorphicInterface(t *testing.T) {
+	t.Run("map to interface field", func(t *testing.T) {
+		source := map[string]interface{}{
+			"Keeper": map[string]interface{}{"kind": "dog", "Name": "rex"},
+			"Animals": []interface{}{
+				map[string]interface{}{"kind": "cat", "Name": "tom", "Lives": int64(9)},
+				map[string]string{"kind": "dog", "Name": "max"},
+			},
+		}
+
+		var target Zoo
+		err := go_deep_copy.DeepCopy(&source, &target)
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if dog, ok := target.Keeper.(Dog); !ok || dog.Name != "rex" {
+			t.Errorf("Keeper should be Dog rex: got %#v", target.Keeper)
+		}
+		if len(target.Animals) != 2 {
+			t.Fatalf("Animals length mismatch: got %d", len(target.Animals))
+		}
+		if cat, ok := target.Animals[0].(*Cat); !ok || cat.Name != "tom" || cat.Lives != 9 {
+			t.Errorf("Animals[0] should be *Cat tom: got %#v", target.Animals[0])
+		}
+		if dog, ok := target.Animals[1].(Dog); !ok || dog.Name != "max" {
+			t.Errorf("Animals[1] should be Dog max: got %#v", target.Animals[1])
+		}
+	})
+
+	t.Run("struct to map emits discriminator", func(t *testing.T) {
+		source := Cat{Name: "tom", Lives: 9}
+		var target map[string]interface{}
+		err := go_deep_copy.DeepCopy(&source, &target)
+		if err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if target["kind"] != "cat" || target["Name"] != "tom" {
+			t.Errorf("discriminator not emitted: got %v", target)
+		}
+	})
+
+	t.Run("round trip through json types", func(t *testing.T) {
+		source := Zoo{
+			Keeper:  Dog{Name: "rex"},
+			Animals: []Animal{&Cat{Name: "tom", Lives: 9}},
+		}
+		copier := go_deep_copy.NewCopier(go_deep_copy.WithInterfaceMode(go_deep_copy.InterfaceJSON))
+
+		var doc map[string]interface{}
+		err := copier.DeepCopy(&source, &doc)
+		if err != nil {
+			t.Fatalf("Copy to map failed: %v", err)
+		}
+		var target Zoo
+		err = copier.DeepCopy(&doc, &target)
+		if err != nil {
+			t.Fatalf("Copy to struct failed: %v", err)
+		}
+		if dog, ok := target.Keeper.(Dog); !ok || dog.Name != "rex" {
+			t.Errorf("Keeper lost its type: got %#v", target.Keeper)
+		}
+		if cat, ok := target.Animals[0].(*Cat); !ok || cat.Lives != 9 {
+			t.Errorf("Animals[0] lost its type: got %#v", target.Animals[0])
+		}
+	})
+
+	t.Run("round trip through maps", func(t *testing.T) {
+		// 嵌套在结构体中的接口字段与 []Animal 同样写入判别字段
+		source := map[string]interface{}{
+			"Keeper": map[string]interface{}{"kind": "dog", "Name": "rex"},
+			"Animals": []interface{}{
+				map[string]interface{}{"kind": "cat", "Name": "tom", "Lives": int64(9)},
+				map[string]interface{}{"kind": "dog", "Name": "max"},
+			},
+		}
+		var zoo Zoo
+		if err := go_deep_copy.DeepCopy(&source, &zoo); err != nil {
+			t.Fatalf("Copy to struct failed: %v", err)
+		}
+		var doc map[string]interface{}
+		if err := go_deep_copy.DeepCopy(&zoo, &doc); err != nil {
+			t.Fatalf("Copy to map failed: %v", err)
+		}
+		if !reflect.DeepEqual(doc, source) {
+			t.Errorf("got %#v, want %#v", doc, source)
+		}
+
+		// Clone 保留具体类型
+		clone, err := go_deep_copy.Clone(zoo)
+		if err != nil {
+			t.Fatalf("Clone failed: %v", err)
+		}
+		if _, ok := clone.Animals[0].(*Cat); !ok {
+			t.Errorf("Clone should keep *Cat: got %#v", clone.Animals[0])
+		}
+	})
+
+	t.Run("strict copier", func(t *testing.T) {
+		// 判别字段不视为具体类型的未知字段
+		strict := go_deep_copy.NewCopier(go_deep_copy.WithDisallowUnknownFields())
+		source := map[string]interface{}{
+			"Keeper":  map[string]interface{}{"kind": "dog", "Name": "rex"},
+			"Animals": []interface{}{map[string]interface{}{"kind": "cat", "Name": "tom"}},
+		}
+		var target Zoo
+		if err := strict.DeepCopy(&source, &target); err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if dog, ok := target.Keeper.(Dog); !ok || dog.Name != "rex" {
+			t.Errorf("Keeper should be Dog rex: got %#v", target.Keeper)
+		}
+
+		var fieldsErr *go_deep_copy.FieldsError
+		source["Keeper"] = map[string]interface{}{"kind": "dog", "Name": "rex", "Age": 3}
+		if err := strict.DeepCopy(&source, &target); !errors.As(err, &fieldsErr) || !reflect.DeepEqual(fieldsErr.Unknown, []string{"Age"}) {
+			t.Errorf("expected Age to be unknown, got %v", err)
+		}
+	})
+
+	t.Run("unknown discriminator", func(t *testing.T) {
+		source := map[string]interface{}{
+			"Keeper": map[string]interface{}{"kind": "bird"},
+		}
+		var target Zoo
+		err := go_deep_copy.DeepCopy(&source, &target)
+		if !errors.Is(err, go_deep_copy.ErrUnknownImplementation) {
+			t.Errorf("expected ErrUnknownImplementation, got %v", err)
+		}
+	})
+
+	t.Run("missing discriminator", func(t *testing.T) {
+		sources := []interface{}{
+			&map[string]interface{}{"Keeper": map[string]interface{}{"Name": "rex"}},
+			&map[string]interface{}{"Keeper": map[string]interface{}{"kind": nil}},
+			&map[string]interface{}{"Keeper": map[string]string{"Name": "rex"}},
+		}
+		for _, source := range sources {
+			var target Zoo
+			err := go_deep_copy.DeepCopy(source, &target)
+			if !errors.Is(err, go_deep_copy.ErrUnknownImplementation) || !strings.Contains(err.Error(), "missing kind") {
+				t.Errorf("expected ErrUnknownImplementation for the missing kind, got %v", err)
+			}
+		}
+
+		var keeper Animal
+		if err := go_deep_copy.DeepCopy(&map[string]string{"Name": "rex"}, &keeper); !errors.Is(err, go_deep_copy.ErrUnknownImplementation) {
+			t.Errorf("expected ErrUnknownImplementation, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package go_deep_copy_test
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+// TestSnapshot 测试 Snapshot 在首次 Mutable 时才深拷贝
+func TestSnapshot(t *testing.T) {
+	source := newMaskOrder()
+	snapshot := go_deep_copy.NewSnapshot(&source)
+	if snapshot.Load() != &source {
+		t.Fatal("snapshot should share the original before Mutable")
+	}
+
+	mutable, err := snapshot.Mutable()
+	if err != nil {
+		t.Fatalf("Mutable failed: %v", err)
+	}
+	if mutable == &source || !reflect.DeepEqual(*mutable, source) {
+		t.Fatalf("Mutable should return a deep copy: %+v", mutable)
+	}
+	mutable.Customer.Name = "Bob"
+	mutable.Items[0].SKU = "changed"
+	mutable.Tags["k"] = "changed"
+	if want := newMaskOrder(); !reflect.DeepEqual(source, want) {
+		t.Errorf("original should not be modified: %+v", source)
+	}
+
+	again, err := snapshot.Mutable()
+	if err != nil || again != mutable || snapshot.Load() != mutable {
+		t.Error("later calls should return the same copy")
+	}
+
+	var empty *Order
+	if zero := go_deep_copy.NewSnapshot(empty).Load(); zero == nil || zero.ID != 0 {
+		t.Errorf("nil value should snapshot the zero value: %v", zero)
+	}
+}
+
+// TestSnapshotShared 测试多个 goroutine 各自的 Snapshot 共享同一原值
+func TestSnapshotShared(t *testing.T) {
+	source := newMaskOrder()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			snapshot := go_deep_copy.NewSnapshot(&source)
+			if snapshot.Load().ID != 7 {
+				t.Error("unexpected shared value")
+			}
+			if i%2 == 0 {
+				mutable, err := snapshot.Mutable()
+				if err != nil {
+					t.Errorf("Mutable failed: %v", err)
+					return
+				}
+				mutable.Items[0].Count = int32(i)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if source.Items[0].Count != 1 {
+		t.Error("original should not be modified")
+	}
+}
+
+// TestSnapshotError 测试深拷贝失败时 Mutable 返回错误且仍共享原值
+func TestSnapshotError(t *testing.T) {
+	source := struct{ Events chan int }{Events: make(chan int)}
+	snapshot := go_deep_copy.NewSnapshot(&source)
+	if _, err := snapshot.Mutable(); !errors.Is(err, go_deep_copy.ErrNotSupported) {
+		t.Fatalf("expected ErrNotSupported, got %v", err)
+	}
+	if snapshot.Load() != &source {
+		t.Error("failed Mutable should keep sharing the original")
+	}
+}
@@ -0,0 +1,110 @@
+package go_deep_copy_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+type SignupForm struct {
+	Email string  `go_deep_copy:"email,required"`
+	Name  *string `go_deep_copy:"name,required"`
+	Age   int     `go_deep_copy:"age"`
+}
+
+type SignupRequest struct {
+	Email string  `go_deep_copy:"email"`
+	Name  *string `go_deep_copy:"name"`
+	Age   int     `go_deep_copy:"age"`
+	Admin bool    `go_deep_copy:"admin"`
+}
+
+type PartialRequest struct {
+	Name *string `go_deep_copy:"name"`
+}
+
+// TestRequiredFields 测试必填字段校验
+func TestRequiredFields(t *testing.T) {
+	t.Run("map to struct", func(t *testing.T) {
+		source := map[string]interface{}{"name": nil, "age": 20}
+		var target SignupForm
+		err := go_deep_copy.DeepCopy(&source, &target)
+		var fieldsErr *go_deep_copy.FieldsError
+		if !errors.As(err, &fieldsErr) || !errors.Is(err, go_deep_copy.ErrMissingField) {
+			t.Fatalf("expected FieldsError, got %v", err)
+		}
+		if !reflect.DeepEqual(fieldsErr.Missing, []string{"email", "name"}) {
+			t.Errorf("Missing mismatch: got %v", fieldsErr.Missing)
+		}
+		if errors.Is(err, go_deep_copy.ErrUnknownField) {
+			t.Error("no unknown field expected")
+		}
+
+		source = map[string]interface{}{"email": "a@b.c", "name": "Alice"}
+		err = go_deep_copy.DeepCopy(&source, &target)
+		if err != nil || target.Email != "a@b.c" || *target.Name != "Alice" {
+			t.Errorf("Copy failed: %v, %+v", err, target)
+		}
+	})
+
+	t.Run("struct to struct", func(t *testing.T) {
+		var target SignupForm
+		name := "Alice"
+		err := go_deep_copy.DeepCopy(&SignupRequest{Email: "a@b.c", Name: &name}, &target)
+		if err != nil || target.Email != "a@b.c" {
+			t.Fatalf("Copy failed: %v, %+v", err, target)
+		}
+
+		err = go_deep_copy.DeepCopy(&SignupRequest{Email: "a@b.c"}, &target)
+		var fieldsErr *go_deep_copy.FieldsError
+		if !errors.As(err, &fieldsErr) || !reflect.DeepEqual(fieldsErr.Missing, []string{"name"}) {
+			t.Errorf("nil name should be missing: %v", err)
+		}
+
+		err = go_deep_copy.DeepCopy(&PartialRequest{Name: &name}, &target)
+		if !errors.As(err, &fieldsErr) || !reflect.DeepEqual(fieldsErr.Missing, []string{"email"}) {
+			t.Errorf("email should be missing: %v", err)
+		}
+		if !errors.Is(go_deep_copy.Warm[PartialRequest, SignupForm](), go_deep_copy.ErrMissingField) {
+			t.Error("Warm should report the missing field")
+		}
+	})
+
+	t.Run("require all fields", func(t *testing.T) {
+		copier := go_deep_copy.NewCopier(go_deep_copy.WithRequireAllFields())
+		source := map[string]interface{}{"email": "a@b.c", "name": "Alice"}
+		var target SignupForm
+		err := copier.DeepCopy(&source, &target)
+		var fieldsErr *go_deep_copy.FieldsError
+		if !errors.As(err, &fieldsErr) || !reflect.DeepEqual(fieldsErr.Missing, []string{"age"}) {
+			t.Errorf("age should be missing: %v", err)
+		}
+	})
+}
+
+// TestDisallowUnknownFields 测试严格模式下的多余字段校验
+func TestDisallowUnknownFields(t *testing.T) {
+	source := map[string]interface{}{"email": "a@b.c", "name": "Alice", "role": "x", "admin": true}
+	var target SignupForm
+	if err := go_deep_copy.DeepCopy(&source, &target); err != nil {
+		t.Fatalf("unknown keys should be ignored by default: %v", err)
+	}
+
+	copier := go_deep_copy.NewCopier(go_deep_copy.WithDisallowUnknownFields())
+	err := copier.DeepCopy(&source, &target)
+	var fieldsErr *go_deep_copy.FieldsError
+	if !errors.As(err, &fieldsErr) || !errors.Is(err, go_deep_copy.ErrUnknownField) {
+		t.Fatalf("expected unknown fields error, got %v", err)
+	}
+	if !reflect.DeepEqual(fieldsErr.Unknown, []string{"admin", "role"}) {
+		t.Errorf("Unknown mismatch: got %v", fieldsErr.Unknown)
+	}
+
+	name := "Alice"
+	err = copier.DeepCopy(&SignupRequest{Email: "a@b.c", Name: &name}, &target)
+	if !errors.As(err, &fieldsErr) || !reflect.DeepEqual(fieldsErr.Unknown, []string{"admin"}) {
+		t.Errorf("struct source with extra field should fail: %v", err)
+	}
+}
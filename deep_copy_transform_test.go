@@ -0,0 +1,85 @@
+package go_deep_copy_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/LiZhiqiang0/go_deep_copy"
+)
+
+type ContactSource struct {
+	Email string
+	Phone string
+	Age   string
+	Score int
+}
+
+type ContactDTO struct {
+	Email string `go_deep_copy:"Email,transform=trim|lower"`
+	Phone string `go_deep_copy:"Phone,transform=mask"`
+	Age   int    `go_deep_copy:"Age,transform=trim"`
+	Score uint   `go_deep_copy:"Score,transform=positive"`
+}
+
+type UnknownTransformDTO struct {
+	Email string `go_deep_copy:"Email,transform=nope"`
+}
+
+type MismatchTransformDTO struct {
+	Score int `go_deep_copy:"Score,transform=lower"`
+}
+
+var errNegative = errors.New("negative")
+
+// TestTransforms 测试标签中引用的字段转换
+func TestTransforms(t *testing.T) {
+	copier := newContactCopier()
+	if err := copier.Precompile(go_deep_copy.PairOf[ContactSource, ContactDTO]()); err != nil {
+		t.Fatalf("Precompile failed: %v", err)
+	}
+
+	source := ContactSource{Email: "  Alice@Example.COM ", Phone: "13800001234", Age: " 30 ", Score: 5}
+	var target ContactDTO
+	err := copier.DeepCopy(&source, &target)
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if target.Email != "alice@example.com" || target.Phone != "*******1234" || target.Age != 30 || target.Score != 5 {
+		t.Errorf("transforms not applied: %+v", target)
+	}
+	if source.Email != "  Alice@Example.COM " {
+		t.Error("source should not be modified")
+	}
+
+	source.Score = -1
+	err = copier.DeepCopy(&source, &target)
+	if !errors.Is(err, errNegative) {
+		t.Errorf("expected transform error, got %v", err)
+	}
+}
+
+// TestTransformValidation 测试构建计划时对字段转换的校验
+func TestTransformValidation(t *testing.T) {
+	copier := newContactCopier()
+
+	err := copier.Precompile(go_deep_copy.PairOf[ContactSource, UnknownTransformDTO]())
+	if !errors.Is(err, go_deep_copy.ErrUnknownTransform) {
+		t.Errorf("expected ErrUnknownTransform, got %v", err)
+	}
+	var target UnknownTransformDTO
+	err = copier.DeepCopy(&ContactSource{}, &target)
+	if !errors.Is(err, go_deep_copy.ErrUnknownTransform) {
+		t.Errorf("expected ErrUnknownTransform, got %v", err)
+	}
+
+	err = copier.Precompile(go_deep_copy.PairOf[ContactSource, MismatchTransformDTO]())
+	if !errors.Is(err, go_deep_copy.ErrUnknownTransform) || !strings.Contains(err.Error(), "does not accept int") {
+		t.Errorf("expected type mismatch, got %v", err)
+	}
+
+	err = go_deep_copy.Warm[ContactSource, ContactDTO]()
+	if !errors.Is(err, go_deep_copy.ErrUnknownTransform) {
+		t.Errorf("mask is not registered on the default copier: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package go_deep_copy
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+var (
+	stringType   = reflect2.TypeOf("")
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// DefaultError is returned when the default=... tag option of a field can not
+// be converted to the field type.
+type DefaultError struct {
+	Struct reflect.Type
+	Field  string
+	Value  string
+	Err    error
+}
+
+func (e *DefaultError) Error() string {
+	return fmt.Sprintf("%s.%s: invalid default %q: %v", e.Struct, e.Field, e.Value, e.Err)
+}
+
+func (e *DefaultError) Unwrap() error {
+	return e.Err
+}
+
+// defaultOp 返回把字段默认值写入目标的函数，默认值按 string -> T 的转换规则解析，构建计划时即校验；
+// 切片（[]byte、[]rune 除外）的默认值按逗号拆分后逐个转换。每次写入都重新转换，避免切片、指针等默认值在多次拷贝间共享
+func (c *Copier) defaultOp(owner reflect2.Type, b *Binding) (func(t unsafe.Pointer) error, error) {
+	if b.defaultValue == nil {
+		return nil, nil
+	}
+	value := *b.defaultValue
+	typ := b.Field.Type()
+	if strings.HasPrefix(value, "'") {
+		// Lookup 已去掉成对的引号，剩下的引号说明没有闭合
+		return nil, &DefaultError{Struct: owner.Type1(), Field: b.Name, Value: value, Err: errors.New("unterminated quote")}
+	}
+	var setDefault func(t unsafe.Pointer) error
+	if elem, ok := envListElem(typ); ok {
+		setDefault = c.defaultListOp(typ.(*reflect2.UnsafeSliceType), elem, value)
+	} else {
+		cvtFunc := c.LoadConvertFunc(stringType, typ)
+		setDefault = func(t unsafe.Pointer) error {
+			return cvtFunc(rt.Value{
+				Ptr: unsafe.Pointer(&value),
+				Typ: stringType,
+			}, rt.Value{
+				Ptr: t,
+				Typ: typ,
+			})
+		}
+	}
+	if err := setDefault(typ.UnsafeNew()); err != nil {
+		return nil, &DefaultError{Struct: owner.Type1(), Field: b.Name, Value: value, Err: err}
+	}
+	return setDefault, nil
+}
+
+// defaultListOp 返回把逗号分隔的默认值写入切片的函数，空值写入 nil 切片
+func (c *Copier) defaultListOp(typ *reflect2.UnsafeSliceType, elem reflect2.Type, value string) func(t unsafe.Pointer) error {
+	var parts []string
+	if value != "" {
+		parts = strings.Split(value, ",")
+	}
+	cvtFunc := c.LoadConvertFunc(stringType, elem)
+	return func(t unsafe.Pointer) error {
+		if parts == nil {
+			typ.UnsafeSetNil(t)
+			return nil
+		}
+		slicePtr := c.makeSlice(typ, len(parts))
+		for i, part := range parts {
+			part = strings.TrimSpace(part)
+			err := cvtFunc(rt.Value{
+				Ptr: unsafe.Pointer(&part),
+				Typ: stringType,
+			}, rt.Value{
+				Ptr: typ.UnsafeGetIndex(slicePtr, i),
+				Typ: elem,
+			})
+			if err != nil {
+				return errAtIndex(err, i)
+			}
+		}
+		typ.UnsafeSet(t, slicePtr)
+		return nil
+	}
+}
+
+// defaultsError 返回结构体中无法解析的默认值
+func (c *Copier) defaultsError(info StructDescriptor) error {
+	for _, b := range info.Fields {
+		if _, err := c.defaultOp(info.Type, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package go_deep_copy
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unsafe"
+
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// EnvSeparator separates the names of nested fields in environment keys:
+// with prefix "APP_", field DB.Host is bound from APP_DB__HOST.
+const EnvSeparator = "__"
+
+// ParseEnviron returns the "key=value" entries of environ, such as
+// os.Environ(), as a map. Entries without "=" are skipped.
+func ParseEnviron(environ []string) map[string]string {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if i := strings.IndexByte(kv, '='); i > 0 {
+			env[kv[:i]] = kv[i+1:]
+		}
+	}
+	return env
+}
+
+// BindEnv binds env with the default Copier; see Copier.BindEnv.
+func BindEnv(env map[string]string, prefix string, toValue interface{}) error {
+	return defaultCopier.BindEnv(env, prefix, toValue)
+}
+
+// BindEnv binds flat keys such as environment variables to the struct toValue
+// points to. The key of a field is prefix followed by the upper-cased tag or Go
+// names on its path joined by EnvSeparator, so with prefix "APP_" the field
+// DB.Host is bound from APP_DB__HOST.
+//
+// Values are parsed with the same conversions as copying from a string,
+// including durations and encoding.TextUnmarshaler types. Slice fields are
+// split on commas, with spaces around the elements trimmed. Fields without a
+// key, or with an empty value, keep their value unless the tag options
+// required or default apply. WithDisallowUnknownFields rejects keys that have
+// the prefix but match no field.
+func (c *Copier) BindEnv(env map[string]string, prefix string, toValue interface{}) error {
+	to := reflect.ValueOf(toValue)
+	if to.Kind() != reflect.Ptr || to.IsNil() || to.Elem().Kind() != reflect.Struct {
+		return ErrInvalidCopyDestination
+	}
+	typ := reflect2.Type2(to.Elem().Type())
+	ptr := unsafe.Pointer(to.Elem().UnsafeAddr())
+	fields := c.flatFields(typ)
+	known := make(map[string]bool, len(fields))
+	var missing []string
+	for _, f := range fields {
+		key := envKey(prefix, f.name)
+		known[key] = true
+		if s := env[key]; s != "" {
+			if err := c.bindEnvField(f, s, ptr); err != nil {
+				return fmt.Errorf("env %s: %w", key, err)
+			}
+			continue
+		}
+		if c.isRequired(f.binding) {
+			missing = append(missing, key)
+		}
+		setDefault, err := c.defaultOp(f.owner, f.binding)
+		if err != nil {
+			return err
+		}
+		if setDefault != nil {
+			if err := setDefault(f.path.write(ptr, c)); err != nil {
+				return err
+			}
+		}
+	}
+	var unknown []string
+	if c.disallowUnknownFields {
+		for key := range env {
+			if strings.HasPrefix(key, prefix) && !known[key] {
+				unknown = append(unknown, key)
+			}
+		}
+		sort.Strings(unknown)
+	}
+	if len(missing) > 0 || len(unknown) > 0 {
+		return &FieldsError{Type: typ.Type1(), Missing: missing, Unknown: unknown}
+	}
+	return nil
+}
+
+// bindEnvField 解析 s 并写入 f，切片按逗号拆分
+func (c *Copier) bindEnvField(f *flatField, s string, ptr unsafe.Pointer) error {
+	typ := f.path.typ
+	if c.redacts(f.path.sensitive) {
+		redact := c.redactOp(stringType, typ)
+		return redact(rt.Value{Typ: stringType, Ptr: unsafe.Pointer(&s)}, rt.Value{Typ: typ, Ptr: f.path.write(ptr, c)})
+	}
+	if elem, ok := envListElem(typ); ok {
+		parse, err := c.parseOp(elem)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(s, ",")
+		sliceType := typ.(*reflect2.UnsafeSliceType)
+		slicePtr := c.makeSlice(sliceType, len(parts))
+		for i, part := range parts {
+			if err := parse(strings.TrimSpace(part), sliceType.UnsafeGetIndex(slicePtr, i)); err != nil {
+				return err
+			}
+		}
+		typ.UnsafeSet(f.path.write(ptr, c), slicePtr)
+		return nil
+	}
+	parse, err := c.parseOp(typ)
+	if err != nil {
+		return err
+	}
+	return parse(s, f.path.write(ptr, c))
+}
+
+// FlattenEnv flattens a struct with the default Copier; see Copier.FlattenEnv.
+func FlattenEnv(fromValue interface{}, prefix string) (map[string]string, error) {
+	return defaultCopier.FlattenEnv(fromValue, prefix)
+}
+
+// FlattenEnv flattens the struct fromValue holds or points to into the keys
+// BindEnv reads, for dumping config. Slices are joined with commas, fields
+// behind nil pointers are omitted, and sensitive fields follow the redaction
+// policy of c.
+func (c *Copier) FlattenEnv(fromValue interface{}, prefix string) (map[string]string, error) {
+	typ, ptr, err := csvStruct(fromValue)
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string)
+	for _, f := range c.flatFields(typ) {
+		key := envKey(prefix, f.name)
+		fieldPtr := f.path.read(ptr)
+		if fieldPtr == nil {
+			continue
+		}
+		var s string
+		if c.redacts(f.path.sensitive) {
+			if c.redaction == RedactDrop {
+				continue
+			}
+			redact := c.redactOp(f.path.typ, stringType)
+			err = redact(rt.Value{Typ: f.path.typ, Ptr: fieldPtr}, rt.Value{Typ: stringType, Ptr: unsafe.Pointer(&s)})
+		} else {
+			s, err = c.flattenEnvField(f.path.typ, fieldPtr)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("env %s: %w", key, err)
+		}
+		env[key] = s
+	}
+	return env, nil
+}
+
+// flattenEnvField 将 ptr 处 typ 的值格式化为字符串，切片以逗号连接
+func (c *Copier) flattenEnvField(typ reflect2.Type, ptr unsafe.Pointer) (string, error) {
+	elem, ok := envListElem(typ)
+	if !ok {
+		format, err := c.formatOp(typ)
+		if err != nil {
+			return "", err
+		}
+		return format(ptr)
+	}
+	format, err := c.formatOp(elem)
+	if err != nil {
+		return "", err
+	}
+	sliceType := typ.(*reflect2.UnsafeSliceType)
+	parts := make([]string, sliceType.UnsafeLengthOf(ptr))
+	for i := range parts {
+		if parts[i], err = format(sliceType.UnsafeGetIndex(ptr, i)); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// envListElem 返回按逗号拆分的切片的元素类型；[]byte、[]rune 与文本类型作为单个字符串处理
+func envListElem(typ reflect2.Type) (reflect2.Type, bool) {
+	if typ.Kind() != reflect.Slice || isTextType(typ) {
+		return nil, false
+	}
+	elem := typ.(reflect2.SliceType).Elem()
+	if k := elem.Kind(); k == reflect.Uint8 || k == reflect.Int32 {
+		return nil, false
+	}
+	return elem, true
+}
+
+// envKey 返回点号路径 name 对应的键，如 db.host -> APP_DB__HOST
+func envKey(prefix, name string) string {
+	return prefix + strings.ToUpper(strings.ReplaceAll(name, ".", EnvSeparator))
+}
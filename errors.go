@@ -1,9 +1,62 @@
 package go_deep_copy
 
-import "errors"
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
 
 var (
 	ErrInvalidCopyDestination = errors.New("copy destination must be non-nil and addressable")
 	ErrInvalidCopyFrom        = errors.New("copy from must be non-nil and addressable")
 	ErrNotSupported           = errors.New("not supported")
+	ErrNoCopy                 = errors.New("type must not be copied")
+	ErrUnknownImplementation  = errors.New("unknown implementation")
+	ErrMissingField           = errors.New("missing required field")
+	ErrUnknownField           = errors.New("unknown field")
+	ErrUnknownTransform       = errors.New("unknown transform")
+	ErrInvalidMapping         = errors.New("invalid mapping")
+	ErrInvalidMask            = errors.New("invalid field mask")
+	ErrLimitExceeded          = errors.New("limit exceeded")
 )
+
+// InterfaceError is returned when a value is copied into an interface
+// destination that neither its type nor its pointer type implements.
+type InterfaceError struct {
+	Type      reflect.Type
+	Interface reflect.Type
+}
+
+func (e *InterfaceError) Error() string {
+	return e.Type.String() + " does not implement " + e.Interface.String()
+}
+
+// FieldsError is returned when a copy into the struct Type misses required
+// fields or, with WithDisallowUnknownFields, gets source fields or keys the
+// destination does not have. It matches ErrMissingField and ErrUnknownField.
+type FieldsError struct {
+	Type    reflect.Type
+	Missing []string
+	Unknown []string
+}
+
+func (e *FieldsError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing required fields "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Unknown) > 0 {
+		parts = append(parts, "unknown fields "+strings.Join(e.Unknown, ", "))
+	}
+	return e.Type.String() + ": " + strings.Join(parts, "; ")
+}
+
+func (e *FieldsError) Is(target error) bool {
+	switch target {
+	case ErrMissingField:
+		return len(e.Missing) > 0
+	case ErrUnknownField:
+		return len(e.Unknown) > 0
+	}
+	return false
+}
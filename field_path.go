@@ -0,0 +1,197 @@
+package go_deep_copy
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"unsafe"
+
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// fieldHop 访问字段时经过的一层结构体字段，elem 非 nil 时该字段为指向 elem 的指针
+type fieldHop struct {
+	offset uintptr
+	elem   reflect2.Type
+}
+
+// fieldPath 从结构体起点到某个字段的访问路径，hops 为经过的匿名嵌入字段或点号路径中的中间字段
+type fieldPath struct {
+	// name 为 Go 字段名组成的路径，如 Customer.Address.City
+	name   string
+	hops   []fieldHop
+	offset uintptr
+	typ    reflect2.Type
+	// sensitive 表示路径上有标记为 sensitive 的字段
+	sensitive bool
+}
+
+func bindingPath(b *Binding) *fieldPath {
+	return &fieldPath{
+		name:      b.Field.Name(),
+		hops:      b.hops,
+		offset:    b.Field.Offset(),
+		typ:       b.Field.Type(),
+		sensitive: b.sensitive,
+	}
+}
+
+// read 返回字段地址，中间指针为 nil 时返回 nil
+func (p *fieldPath) read(base unsafe.Pointer) unsafe.Pointer {
+	for i := range p.hops {
+		base = pointerOffset(base, p.hops[i].offset)
+		if p.hops[i].elem != nil {
+			base = *(*unsafe.Pointer)(base)
+			if base == nil {
+				return nil
+			}
+		}
+	}
+	return pointerOffset(base, p.offset)
+}
+
+// write 返回字段地址，中间指针为 nil 时通过 c 的分配器创建
+func (p *fieldPath) write(base unsafe.Pointer, c *Copier) unsafe.Pointer {
+	for i := range p.hops {
+		base = pointerOffset(base, p.hops[i].offset)
+		if elem := p.hops[i].elem; elem != nil {
+			ptr := (*unsafe.Pointer)(base)
+			if *ptr == nil {
+				*ptr = c.newValue(elem)
+			}
+			base = *ptr
+		}
+	}
+	return pointerOffset(base, p.offset)
+}
+
+// resolvePath 沿点号路径逐层查找 typ 的嵌套字段，经过的指针字段在读取时判空、写入时创建；
+// 找不到时返回 nil。root 为路径第一段的字段
+func (c *Copier) resolvePath(typ reflect2.Type, path string) (p *fieldPath, root *Binding) {
+	segments := strings.Split(path, ".")
+	p = &fieldPath{}
+	names := make([]string, 0, len(segments))
+	cur := typ
+	for i, segment := range segments {
+		if cur.Kind() != reflect.Struct || isNoCopyType(cur) {
+			return nil, nil
+		}
+		b, ok := c.loadStructFieldsInfo(cur).FieldMap[segment]
+		if !ok {
+			return nil, nil
+		}
+		if i == 0 {
+			root = b
+		}
+		names = append(names, b.Field.Name())
+		p.hops = append(p.hops, b.hops...)
+		p.sensitive = p.sensitive || b.sensitive
+		if i == len(segments)-1 {
+			p.name = strings.Join(names, ".")
+			p.offset = b.Field.Offset()
+			p.typ = b.Field.Type()
+			return p, root
+		}
+		hop := fieldHop{offset: b.Field.Offset()}
+		cur = b.Field.Type()
+		if cur.Kind() == reflect.Ptr {
+			cur = cur.(*reflect2.UnsafePtrType).Elem()
+			hop.elem = cur
+		}
+		p.hops = append(p.hops, hop)
+	}
+	return nil, nil
+}
+
+// fieldMatch 源结构体与目标结构体之间匹配的一对字段，tBinding 提供 required、default 等目标字段选项，
+// 由源字段的点号路径匹配到的嵌套目标字段没有 tBinding
+type fieldMatch struct {
+	v, t     *fieldPath
+	tBinding *Binding
+	// convert 为映射中为该目标字段登记的 Convert 函数
+	convert reflect.Value
+	// getter 为源结构体的取值方法，此时 v 为整个源结构体；setter 为目标结构体的赋值方法，此时 t 为整个目标结构体
+	getter reflect.Value
+	setter reflect.Value
+}
+
+// edge 返回该匹配在计划中按常规规则转换的类型对
+func (m *fieldMatch) edge() (v, t reflect2.Type) {
+	v, t = m.v.typ, m.t.typ
+	switch {
+	case m.getter.IsValid():
+		v = reflect2.Type2(m.getter.Type().Out(0))
+	case m.setter.IsValid():
+		t = reflect2.Type2(m.setter.Type().In(1))
+	case m.convert.IsValid():
+		// Convert 函数的输出再按常规规则转换为目标字段
+		if out := convertOutType(m.convert); out != nil {
+			v = out
+		}
+	}
+	return v, t
+}
+
+// order 返回字段的拷贝顺序：先拷贝整体字段，再写入点号路径指向的嵌套字段，避免后者被整体字段覆盖，最后调用赋值方法
+func (m *fieldMatch) order() int {
+	if m.setter.IsValid() {
+		return math.MaxInt32
+	}
+	return len(m.t.hops)
+}
+
+// matchStructFields 先按 WithMapping 登记的映射匹配字段，其余字段按名称匹配；名称含点号且对方没有同名字段时，
+// 沿对方的嵌套字段解析，如目标字段 `go_deep_copy:"Customer.Address.City"` 读取源的 Customer.Address.City
+func (c *Copier) matchStructFields(v, t reflect2.Type) (matches []fieldMatch, unmatchedV, unmatchedT []*Binding, err error) {
+	vInfo := c.loadStructFieldsInfo(v)
+	tInfo := c.loadStructFieldsInfo(t)
+	usedV := make(map[*Binding]bool, len(vInfo.Fields))
+	usedT := make(map[*Binding]bool, len(tInfo.Fields))
+	if m := c.mappings[[2]uintptr{v.RType(), t.RType()}]; m != nil {
+		if matches, err = c.applyMapping(m, usedV, usedT); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	for _, tf := range tInfo.Fields {
+		if usedT[tf] {
+			continue
+		}
+		if f, ok := vInfo.FieldMap[tf.Name]; ok {
+			matches = append(matches, fieldMatch{v: f.path, t: tf.path, tBinding: tf})
+			usedV[f], usedT[tf] = true, true
+		} else if strings.Contains(tf.Name, ".") {
+			if p, root := c.resolvePath(v, tf.Name); p != nil {
+				matches = append(matches, fieldMatch{v: p, t: tf.path, tBinding: tf})
+				usedV[root], usedT[tf] = true, true
+			}
+		}
+	}
+	for _, f := range vInfo.Fields {
+		if usedV[f] || !strings.Contains(f.Name, ".") {
+			continue
+		}
+		// 目标字段的标签选项属于嵌套结构体，这里不生效
+		if p, root := c.resolvePath(t, f.Name); p != nil {
+			matches = append(matches, fieldMatch{v: f.path, t: p})
+			usedV[f], usedT[root] = true, true
+		}
+	}
+	if c.methods {
+		matches = append(matches, c.matchMethods(v, t, vInfo.Fields, tInfo.Fields, usedV, usedT)...)
+	}
+	for _, f := range vInfo.Fields {
+		if !usedV[f] {
+			unmatchedV = append(unmatchedV, f)
+		}
+	}
+	for _, tf := range tInfo.Fields {
+		if !usedT[tf] {
+			unmatchedT = append(unmatchedT, tf)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].order() < matches[j].order()
+	})
+	return matches, unmatchedV, unmatchedT, nil
+}
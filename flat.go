@@ -0,0 +1,173 @@
+package go_deep_copy
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"time"
+	"unsafe"
+
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// flatField 为扁平格式（CSV、表单、环境变量）中的一个叶子字段，嵌套结构体的字段按点号展开
+type flatField struct {
+	// name 为各层字段名称（标签名优先）以点号连接的路径，如 addr.city
+	name    string
+	path    *fieldPath
+	binding *Binding
+	// owner 为直接声明该字段的结构体
+	owner reflect2.Type
+}
+
+// flatFields 返回 typ 展开后的导出叶子字段；实现了 encoding.TextMarshaler 的结构体（如 time.Time）作为叶子，
+// 递归引用自身的结构体不再展开。路径沿字段逐层拼接而不按名称解析，标签名本身含点号（如 Customer.City）时同样可用
+func (c *Copier) flatFields(typ reflect2.Type) []*flatField {
+	var fields []*flatField
+	var walk func(owner reflect2.Type, prefix, goPrefix string, hops []fieldHop, sensitive bool, visiting map[reflect2.Type]bool)
+	walk = func(owner reflect2.Type, prefix, goPrefix string, hops []fieldHop, sensitive bool, visiting map[reflect2.Type]bool) {
+		visiting[owner] = true
+		defer delete(visiting, owner)
+		for _, b := range c.loadStructFieldsInfo(owner).Fields {
+			if b.Field.PkgPath() != "" {
+				continue
+			}
+			name := b.Name
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			goName := joinPath(goPrefix, b.Field.Name())
+			// 复制一份，避免各字段的 hops 共用底层数组
+			fieldHops := append(append([]fieldHop(nil), hops...), b.hops...)
+			elem := b.Field.Type()
+			hop := fieldHop{offset: b.Field.Offset()}
+			if elem.Kind() == reflect.Ptr {
+				elem = elem.(*reflect2.UnsafePtrType).Elem()
+				hop.elem = elem
+			}
+			if elem.Kind() == reflect.Struct && !isTextType(elem) && !isNoCopyType(elem) {
+				if !visiting[elem] {
+					walk(elem, name, goName, append(fieldHops, hop), sensitive || b.sensitive, visiting)
+				}
+				continue
+			}
+			path := &fieldPath{
+				name:      goName,
+				hops:      fieldHops,
+				offset:    b.Field.Offset(),
+				typ:       b.Field.Type(),
+				sensitive: sensitive || b.sensitive,
+			}
+			fields = append(fields, &flatField{name: name, path: path, binding: b, owner: owner})
+		}
+	}
+	walk(typ, "", "", nil, false, map[reflect2.Type]bool{})
+	return fields
+}
+
+// isTextType 判断 typ 是否通过 encoding.TextMarshaler 与 encoding.TextUnmarshaler 和字符串互转
+func isTextType(typ reflect2.Type) bool {
+	ptrType := reflect.PtrTo(typ.Type1())
+	return ptrType.Implements(textMarshalerType) && ptrType.Implements(textUnmarshalerType)
+}
+
+// formatOp 返回把 typ 的值格式化为字符串的函数，整数、布尔值与字符串沿用 T -> string 的转换，
+// nil 指针为空字符串
+func (c *Copier) formatOp(typ reflect2.Type) (func(ptr unsafe.Pointer) (string, error), error) {
+	t1 := typ.Type1()
+	switch {
+	case reflect.PtrTo(t1).Implements(textMarshalerType):
+		return func(ptr unsafe.Pointer) (string, error) {
+			text, err := reflect.NewAt(t1, ptr).Interface().(encoding.TextMarshaler).MarshalText()
+			return string(text), err
+		}, nil
+	case typ.Kind() == reflect.Ptr:
+		format, err := c.formatOp(typ.(*reflect2.UnsafePtrType).Elem())
+		if err != nil {
+			return nil, err
+		}
+		return func(ptr unsafe.Pointer) (string, error) {
+			if p := *(*unsafe.Pointer)(ptr); p != nil {
+				return format(p)
+			}
+			return "", nil
+		}, nil
+	case t1 == durationType:
+		return func(ptr unsafe.Pointer) (string, error) {
+			return (*time.Duration)(ptr).String(), nil
+		}, nil
+	}
+	switch getKind(typ) {
+	case reflect.Float32:
+		bits := t1.Bits()
+		return func(ptr unsafe.Pointer) (string, error) {
+			return strconv.FormatFloat(rt.Value{Typ: typ, Ptr: ptr}.Float(), 'g', -1, bits), nil
+		}, nil
+	case reflect.Slice:
+		if k := typ.(reflect2.SliceType).Elem().Kind(); k != reflect.Uint8 && k != reflect.Int32 {
+			return nil, ErrNotSupported
+		}
+	case reflect.Int, reflect.Uint, reflect.Bool, reflect.String:
+	default:
+		return nil, ErrNotSupported
+	}
+	cvtFunc := c.LoadConvertFunc(typ, stringType)
+	return func(ptr unsafe.Pointer) (string, error) {
+		var s string
+		err := cvtFunc(rt.Value{Typ: typ, Ptr: ptr}, rt.Value{Typ: stringType, Ptr: unsafe.Pointer(&s)})
+		return s, err
+	}, nil
+}
+
+// parseOp 返回把字符串解析为 typ 的值的函数，沿用 string -> T 的转换；空字符串写入零值
+func (c *Copier) parseOp(typ reflect2.Type) (func(s string, ptr unsafe.Pointer) error, error) {
+	t1 := typ.Type1()
+	switch {
+	case reflect.PtrTo(t1).Implements(textUnmarshalerType):
+		return func(s string, ptr unsafe.Pointer) error {
+			if s == "" {
+				typ.UnsafeSet(ptr, typ.UnsafeNew())
+				return nil
+			}
+			return reflect.NewAt(t1, ptr).Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		}, nil
+	case typ.Kind() == reflect.Ptr:
+		elem := typ.(*reflect2.UnsafePtrType).Elem()
+		parse, err := c.parseOp(elem)
+		if err != nil {
+			return nil, err
+		}
+		return func(s string, ptr unsafe.Pointer) error {
+			if s == "" {
+				*(*unsafe.Pointer)(ptr) = nil
+				return nil
+			}
+			p := c.newValue(elem)
+			if err := parse(s, p); err != nil {
+				return err
+			}
+			*(*unsafe.Pointer)(ptr) = p
+			return nil
+		}, nil
+	}
+	switch getKind(typ) {
+	case reflect.Slice:
+		if k := typ.(reflect2.SliceType).Elem().Kind(); k != reflect.Uint8 && k != reflect.Int32 {
+			return nil, ErrNotSupported
+		}
+	case reflect.Int, reflect.Uint, reflect.Float32, reflect.Bool, reflect.String:
+	default:
+		return nil, ErrNotSupported
+	}
+	cvtFunc := c.LoadConvertFunc(stringType, typ)
+	return func(s string, ptr unsafe.Pointer) error {
+		if s == "" {
+			typ.UnsafeSet(ptr, typ.UnsafeNew())
+			return nil
+		}
+		return cvtFunc(rt.Value{Typ: stringType, Ptr: unsafe.Pointer(&s)}, rt.Value{Typ: typ, Ptr: ptr})
+	}, nil
+}
@@ -0,0 +1,401 @@
+package go_deep_copy
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// maxFormIndex 为表单键中切片下标的默认上限，防止 items[99999999] 这样的键分配过多内存
+const maxFormIndex = 10000
+
+// errUnknownFormKey 表示表单键在目标结构体中没有对应的字段
+var errUnknownFormKey = errors.New("unknown form key")
+
+// formSegment 为表单键中的一段，index 为 -1 时是名称
+type formSegment struct {
+	name  string
+	index int
+}
+
+// BindForm binds form values with the default Copier; see Copier.BindForm.
+func BindForm(values url.Values, toValue interface{}) error {
+	return defaultCopier.BindForm(values, toValue)
+}
+
+// BindForm binds form values, such as the url.Values of an HTTP request, to the
+// struct toValue points to. Keys are field paths of tag names or Go names:
+//
+//	name=Alice            // Name
+//	addr.city=Paris       // Addr.City, also addr[city]
+//	tags=a&tags=b         // Tags []string takes all the values
+//	items[0].id=7         // Items[0].ID
+//
+// Single-valued fields take the first value, slice fields take all of them, and
+// values are parsed with the same conversions as copying from a string. The tag
+// options required and default apply to fields without a key, keys matching
+// no field are ignored unless WithDisallowUnknownFields is set, and slice
+// indexes are limited to 10000, or to Limits.MaxElements when it is set.
+func (c *Copier) BindForm(values url.Values, toValue interface{}) error {
+	to := reflect.ValueOf(toValue)
+	if to.Kind() != reflect.Ptr || to.IsNil() || to.Elem().Kind() != reflect.Struct {
+		return ErrInvalidCopyDestination
+	}
+	root := to.Elem()
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	// 按键排序，错误与切片的增长顺序与 map 的遍历顺序无关
+	sort.Strings(keys)
+	present := make(map[string]bool, len(keys))
+	var unknown []string
+	for _, key := range keys {
+		segments, ok := parseFormKey(key)
+		var err error
+		if ok {
+			err = c.bindForm(root, segments, values[key])
+		} else {
+			err = errUnknownFormKey
+		}
+		if err == errUnknownFormKey {
+			if c.disallowUnknownFields {
+				unknown = append(unknown, key)
+			}
+			continue
+		}
+		if err != nil {
+			var limitErr *LimitError
+			if errors.As(err, &limitErr) {
+				limitErr.Path = key
+				return err
+			}
+			return fmt.Errorf("form key %s: %w", key, err)
+		}
+		// 记录名称组成的各级前缀，下标之后的部分不参与 required 与 default
+		name := ""
+		for _, s := range segments {
+			if s.index >= 0 {
+				break
+			}
+			name = joinPath(name, s.name)
+			present[name] = true
+		}
+	}
+	typ := reflect2.Type2(root.Type())
+	ptr := unsafe.Pointer(root.UnsafeAddr())
+	var missing []string
+	for _, f := range c.flatFields(typ) {
+		if present[f.name] {
+			continue
+		}
+		if c.isRequired(f.binding) {
+			missing = append(missing, f.name)
+		}
+		setDefault, err := c.defaultOp(f.owner, f.binding)
+		if err != nil {
+			return err
+		}
+		if setDefault != nil {
+			if err := setDefault(f.path.write(ptr, c)); err != nil {
+				return err
+			}
+		}
+	}
+	if len(missing) > 0 || len(unknown) > 0 {
+		return &FieldsError{Type: typ.Type1(), Missing: missing, Unknown: unknown}
+	}
+	return nil
+}
+
+// bindForm 沿 segments 找到 v 中的目标位置并写入 values，中间的指针、切片与 map 按需创建
+func (c *Copier) bindForm(v reflect.Value, segments []formSegment, values []string) error {
+	if len(segments) == 0 {
+		return c.bindFormValues(v, values)
+	}
+	seg := segments[0]
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return c.bindForm(v.Elem(), segments, values)
+	case reflect.Struct:
+		if seg.index >= 0 || isTextType(reflect2.Type2(v.Type())) {
+			return errUnknownFormKey
+		}
+		b, n := c.formField(reflect2.Type2(v.Type()), segments)
+		if b == nil {
+			return errUnknownFormKey
+		}
+		fieldType := b.Field.Type()
+		fieldPtr := b.path.write(unsafe.Pointer(v.UnsafeAddr()), c)
+		if len(segments) == n && c.redacts(b.sensitive) {
+			redact := c.redactOp(stringType, fieldType)
+			value := firstValue(values)
+			return redact(rt.Value{Typ: stringType, Ptr: unsafe.Pointer(&value)}, rt.Value{Typ: fieldType, Ptr: fieldPtr})
+		}
+		return c.bindForm(reflect.NewAt(fieldType.Type1(), fieldPtr).Elem(), segments[n:], values)
+	case reflect.Slice:
+		if seg.index < 0 {
+			return errUnknownFormKey
+		}
+		max := maxFormIndex
+		if c.limits.MaxElements > 0 {
+			max = c.limits.MaxElements
+		}
+		if seg.index >= max {
+			return &LimitError{Limit: "elements", Max: max}
+		}
+		if seg.index >= v.Len() {
+			grown := reflect.MakeSlice(v.Type(), seg.index+1, seg.index+1)
+			reflect.Copy(grown, v)
+			v.Set(grown)
+		}
+		return c.bindForm(v.Index(seg.index), segments[1:], values)
+	case reflect.Array:
+		if seg.index < 0 || seg.index >= v.Len() {
+			return errUnknownFormKey
+		}
+		return c.bindForm(v.Index(seg.index), segments[1:], values)
+	case reflect.Map:
+		if seg.index >= 0 || v.Type().Key().Kind() != reflect.String {
+			return errUnknownFormKey
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		key := reflect.New(v.Type().Key()).Elem()
+		key.SetString(seg.name)
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if old := v.MapIndex(key); old.IsValid() {
+			elem.Set(old)
+		}
+		if err := c.bindForm(elem, segments[1:], values); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+		return nil
+	}
+	return errUnknownFormKey
+}
+
+// formField 返回 segments 开头的名称对应的导出字段及其占用的段数；标签名本身含点号（如 Customer.City）时
+// 占用多段，优先匹配最长的名称
+func (c *Copier) formField(typ reflect2.Type, segments []formSegment) (*Binding, int) {
+	fieldMap := c.loadStructFieldsInfo(typ).FieldMap
+	n := 0
+	for n < len(segments) && segments[n].index < 0 {
+		n++
+	}
+	for ; n > 0; n-- {
+		names := make([]string, n)
+		for i := range names {
+			names[i] = segments[i].name
+		}
+		if b, ok := fieldMap[strings.Join(names, ".")]; ok && b.Field.PkgPath() == "" {
+			return b, n
+		}
+	}
+	return nil, 0
+}
+
+// bindFormValues 写入键对应的值：切片（[]byte 除外）取全部值，其余取第一个值
+func (c *Copier) bindFormValues(v reflect.Value, values []string) error {
+	typ := reflect2.Type2(v.Type())
+	if v.Kind() == reflect.Slice && !isTextType(typ) {
+		elem := typ.(reflect2.SliceType).Elem()
+		if k := elem.Kind(); k != reflect.Uint8 && k != reflect.Int32 {
+			parse, err := c.parseOp(elem)
+			if err != nil {
+				return err
+			}
+			slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+			for i, value := range values {
+				if err := parse(value, unsafe.Pointer(slice.Index(i).UnsafeAddr())); err != nil {
+					return err
+				}
+			}
+			v.Set(slice)
+			return nil
+		}
+	}
+	parse, err := c.parseOp(typ)
+	if err != nil {
+		return err
+	}
+	return parse(firstValue(values), unsafe.Pointer(v.UnsafeAddr()))
+}
+
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// parseFormKey 将 addr.city、items[0].id、addr[city] 等键拆分为名称与下标，结尾的 [] 忽略
+func parseFormKey(key string) ([]formSegment, bool) {
+	var segments []formSegment
+	for key != "" {
+		switch key[0] {
+		case '.':
+			key = key[1:]
+			continue
+		case '[':
+			end := strings.IndexByte(key, ']')
+			if end < 0 {
+				return nil, false
+			}
+			inner := key[1:end]
+			key = key[end+1:]
+			if inner == "" {
+				if key != "" {
+					return nil, false
+				}
+				continue
+			}
+			if index, err := strconv.Atoi(inner); err == nil {
+				if index < 0 {
+					return nil, false
+				}
+				segments = append(segments, formSegment{index: index})
+			} else {
+				segments = append(segments, formSegment{name: inner, index: -1})
+			}
+			continue
+		}
+		end := strings.IndexAny(key, ".[")
+		if end < 0 {
+			end = len(key)
+		}
+		segments = append(segments, formSegment{name: key[:end], index: -1})
+		key = key[end:]
+	}
+	return segments, len(segments) > 0
+}
+
+// EncodeForm encodes a struct with the default Copier; see Copier.EncodeForm.
+func EncodeForm(fromValue interface{}) (url.Values, error) {
+	return defaultCopier.EncodeForm(fromValue)
+}
+
+// EncodeForm encodes the struct fromValue holds or points to as url.Values, the
+// reverse of BindForm: nested fields get dotted keys, slices of scalars
+// repeat their key and slices of structs get indexed keys such as
+// "items[0].id". Nil pointers are omitted, and sensitive fields follow the
+// redaction policy of c.
+func (c *Copier) EncodeForm(fromValue interface{}) (url.Values, error) {
+	from := indirect(reflect.ValueOf(fromValue))
+	if from.Kind() != reflect.Struct {
+		return nil, ErrInvalidCopyFrom
+	}
+	if !from.CanAddr() {
+		ptr := reflect.New(from.Type())
+		ptr.Elem().Set(from)
+		from = ptr.Elem()
+	}
+	values := url.Values{}
+	if err := c.encodeForm(values, "", from); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// encodeForm 将 v 写入 values，key 为 v 的路径
+func (c *Copier) encodeForm(values url.Values, key string, v reflect.Value) error {
+	typ := reflect2.Type2(v.Type())
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if isTextType(typ) {
+			break
+		}
+		return c.encodeForm(values, key, v.Elem())
+	case reflect.Struct:
+		if isTextType(typ) {
+			break
+		}
+		for _, b := range c.loadStructFieldsInfo(typ).Fields {
+			if b.Field.PkgPath() != "" {
+				continue
+			}
+			fieldPtr := b.path.read(unsafe.Pointer(v.UnsafeAddr()))
+			if fieldPtr == nil {
+				continue
+			}
+			fieldType := b.Field.Type()
+			name := joinPath(key, b.Name)
+			if c.redacts(b.sensitive) {
+				if c.redaction == RedactDrop {
+					continue
+				}
+				var s string
+				redact := c.redactOp(fieldType, stringType)
+				if err := redact(rt.Value{Typ: fieldType, Ptr: fieldPtr}, rt.Value{Typ: stringType, Ptr: unsafe.Pointer(&s)}); err != nil {
+					return err
+				}
+				values.Set(name, s)
+				continue
+			}
+			if err := c.encodeForm(values, name, reflect.NewAt(fieldType.Type1(), fieldPtr).Elem()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice, reflect.Array:
+		elem := v.Type().Elem()
+		if k := elem.Kind(); (k == reflect.Uint8 || k == reflect.Int32) && v.Kind() == reflect.Slice {
+			break
+		}
+		if format, err := c.formatOp(reflect2.Type2(elem)); err == nil {
+			for i := 0; i < v.Len(); i++ {
+				s, err := format(unsafe.Pointer(v.Index(i).UnsafeAddr()))
+				if err != nil {
+					return fmt.Errorf("form key %s: %w", key, err)
+				}
+				values.Add(key, s)
+			}
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := c.encodeForm(values, key+"["+strconv.Itoa(i)+"]", v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("form key %s: %w", key, ErrNotSupported)
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			if err := c.encodeForm(values, joinPath(key, iter.Key().String()), elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	format, err := c.formatOp(typ)
+	if err != nil {
+		return fmt.Errorf("form key %s: %w", key, err)
+	}
+	s, err := format(unsafe.Pointer(v.UnsafeAddr()))
+	if err != nil {
+		return fmt.Errorf("form key %s: %w", key, err)
+	}
+	values.Set(key, s)
+	return nil
+}
@@ -0,0 +1,232 @@
+package go_deep_copy
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"unsafe"
+
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// InterfaceMode decides which dynamic type a value gets when it is copied
+// into an interface{} destination.
+type InterfaceMode int
+
+const (
+	// InterfaceWiden stores integers as int64, unsigned integers as uint64
+	// and floats as float64. It is the default of DeepCopy.
+	InterfaceWiden InterfaceMode = iota
+	// InterfacePreserve stores a copy of the exact source type, so int32 stays
+	// int32 and named types keep their identity. It is the default of Clone.
+	InterfacePreserve
+	// InterfaceJSON normalises values the way encoding/json decodes them into
+	// interface{}: float64, string, bool, map[string]interface{}, []interface{} and nil.
+	InterfaceJSON
+)
+
+// WithInterfaceMode sets how values are stored into interface{} destinations.
+func WithInterfaceMode(mode InterfaceMode) Option {
+	return func(c *Copier) {
+		c.interfaceMode = mode
+	}
+}
+
+var (
+	mapStringIfaceType = reflect2.TypeOf(map[string]interface{}(nil))
+	sliceIfaceType     = reflect2.TypeOf([]interface{}(nil))
+	jsonMarshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType  = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// unpackEFace 取出 interface{} 中的动态值，st 为所属拷贝的状态；指针形态的值直接存放在数据字中，需要再取一次地址
+func unpackEFace(obj interface{}, st rt.State) rt.Value {
+	typ := reflect2.TypeOf(obj)
+	ptr := reflect2.PtrOf(obj)
+	if typ.LikePtr() {
+		p := new(unsafe.Pointer)
+		*p = ptr
+		ptr = unsafe.Pointer(p)
+	}
+	return rt.Value{Typ: typ, Ptr: ptr, St: st}
+}
+
+// ifaceOp 返回 T -> 非空接口（error、fmt.Stringer 等）的转换函数；
+// 源类型未实现该接口时，若其指针类型实现则存入指针，否则返回 *InterfaceError
+func (c *Copier) ifaceOp(v, t reflect2.Type) func(v, t rt.Value) error {
+	if v.Kind() == reflect.Interface {
+		return c.cvtIToI
+	}
+	t1 := t.Type1()
+	if impls := loadImplementations(t1); impls != nil && v.Kind() == reflect.Map && v.(reflect2.MapType).Key().Kind() == reflect.String {
+		return c.mapToImplementationOp(v, t, impls)
+	}
+	boxType := v
+	switch {
+	case v.Type1().Implements(t1):
+	case reflect.PtrTo(v.Type1()).Implements(t1):
+		boxType = reflect2.PtrTo(v)
+	default:
+		err := &InterfaceError{Type: v.Type1(), Interface: t1}
+		return func(v, t rt.Value) error {
+			return err
+		}
+	}
+	cvtFunc := c.loadEdgeFunc("", "", v, boxType)
+	return func(v, t rt.Value) error {
+		boxPtr := c.newValue(boxType)
+		err := cvtFunc(v, rt.Value{
+			Typ: boxType,
+			Ptr: boxPtr,
+		})
+		if err != nil {
+			return err
+		}
+		// 非空接口的内存布局为 itab + data，需要借助 reflect 构造
+		reflect.NewAt(t1, t.Ptr).Elem().Set(reflect.ValueOf(boxType.UnsafeIndirect(boxPtr)))
+		return nil
+	}
+}
+
+// tToIOp 按 interfaceMode 选择 T -> interface{} 的转换方式
+func (c *Copier) tToIOp(v reflect2.Type) func(v, t rt.Value) error {
+	if op := c.registeredToIfaceOp(v); op != nil {
+		return op
+	}
+	switch c.interfaceMode {
+	case InterfacePreserve:
+		switch v.Kind() {
+		case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		default:
+			c.visitEdge("", "", v, v)
+		}
+		return c.cvtTToIPreserve
+	case InterfaceJSON:
+		return c.jsonOp(v)
+	}
+	switch v.Kind() {
+	case reflect.Map, reflect.Array, reflect.Slice, reflect.Struct:
+		c.visitEdge("", "", v, v)
+	}
+	return c.cvtTToI
+}
+
+// convertOp: T -> interface{}, keep the dynamic type of T
+func (c *Copier) cvtTToIPreserve(v rt.Value, t rt.Value) error {
+	switch v.Typ.Kind() {
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		// 无法深拷贝的引用类型原样保存
+		*(*interface{})(t.Ptr) = v.Typ.UnsafeIndirect(v.Ptr)
+		return nil
+	}
+	vPtr := c.newValue(v.Typ)
+	cvtFunc := c.LoadConvertFunc(v.Typ, v.Typ)
+	err := cvtFunc(v, rt.Value{
+		Typ: v.Typ,
+		Ptr: vPtr,
+	})
+	if err != nil {
+		return err
+	}
+	*(*interface{})(t.Ptr) = v.Typ.UnsafeIndirect(vPtr)
+	return nil
+}
+
+// jsonOp 返回 T -> interface{} 的转换函数，结果与 encoding/json 解码到 interface{} 时的类型一致
+func (c *Copier) jsonOp(v reflect2.Type) func(v, t rt.Value) error {
+	v1 := v.Type1()
+	ptrType := reflect.PtrTo(v1)
+	switch {
+	case ptrType.Implements(jsonMarshalerType):
+		return cvtJSONMarshaler
+	case ptrType.Implements(textMarshalerType):
+		return cvtTextMarshaler
+	}
+	switch getKind(v) {
+	case reflect.Int:
+		return func(v, t rt.Value) error {
+			*(*interface{})(t.Ptr) = float64(v.Int())
+			return nil
+		}
+	case reflect.Uint:
+		return func(v, t rt.Value) error {
+			*(*interface{})(t.Ptr) = float64(v.Uint())
+			return nil
+		}
+	case reflect.Float32:
+		return func(v, t rt.Value) error {
+			*(*interface{})(t.Ptr) = v.Float()
+			return nil
+		}
+	case reflect.Bool:
+		return func(v, t rt.Value) error {
+			*(*interface{})(t.Ptr) = v.Bool()
+			return nil
+		}
+	case reflect.String:
+		return func(v, t rt.Value) error {
+			if err := enterLeaf(v); err != nil {
+				return err
+			}
+			*(*interface{})(t.Ptr) = v.String()
+			return nil
+		}
+	case reflect.Slice:
+		if v1.Elem().Kind() == reflect.Uint8 {
+			// 与 encoding/json 一致，[]byte 编码为 base64 字符串
+			return func(v, t rt.Value) error {
+				if err := enterLeaf(v); err != nil {
+					return err
+				}
+				*(*interface{})(t.Ptr) = base64.StdEncoding.EncodeToString(*(*[]byte)(v.Ptr))
+				return nil
+			}
+		}
+		return c.cvtToJSONContainer(v, sliceIfaceType)
+	case reflect.Array:
+		return c.cvtToJSONContainer(v, sliceIfaceType)
+	case reflect.Map, reflect.Struct:
+		return c.cvtToJSONContainer(v, mapStringIfaceType)
+	}
+	return nil
+}
+
+// cvtToJSONContainer 先转换为 map[string]interface{} 或 []interface{}，再存入 interface{}
+func (c *Copier) cvtToJSONContainer(v, container reflect2.Type) func(v, t rt.Value) error {
+	cvtFunc := c.loadEdgeFunc("", "", v, container)
+	return func(v, t rt.Value) error {
+		cPtr := c.newValue(container)
+		err := cvtFunc(v, rt.Value{
+			Typ: container,
+			Ptr: cPtr,
+		})
+		if err != nil {
+			return err
+		}
+		*(*interface{})(t.Ptr) = container.UnsafeIndirect(cPtr)
+		return nil
+	}
+}
+
+// convertOp: json.Marshaler -> interface{}
+func cvtJSONMarshaler(v, t rt.Value) error {
+	m := reflect.NewAt(v.Typ.Type1(), v.Ptr).Interface().(json.Marshaler)
+	data, err := m.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, (*interface{})(t.Ptr))
+}
+
+// convertOp: encoding.TextMarshaler -> interface{}
+func cvtTextMarshaler(v, t rt.Value) error {
+	m := reflect.NewAt(v.Typ.Type1(), v.Ptr).Interface().(encoding.TextMarshaler)
+	data, err := m.MarshalText()
+	if err != nil {
+		return err
+	}
+	*(*interface{})(t.Ptr) = string(data)
+	return nil
+}
@@ -0,0 +1,204 @@
+package go_deep_copy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// Limits bounds the source values a Copier accepts, for copying untrusted
+// input such as a decoded map[string]interface{}. Zero fields are unlimited.
+type Limits struct {
+	// MaxDepth is the maximum nesting of structs, maps, slices and arrays.
+	MaxDepth int
+	// MaxElements is the maximum total number of map, slice and array
+	// elements in one copy.
+	MaxElements int
+	// MaxStringLen is the maximum length of a string or []byte.
+	MaxStringLen int
+}
+
+// WithLimits makes the Copier check limits while copying. A copy exceeding
+// them stops with a *LimitError, leaving the destination partially written.
+func WithLimits(limits Limits) Option {
+	return func(c *Copier) {
+		c.limits = limits
+	}
+}
+
+// LimitError is returned when a copy exceeds the Limits of its Copier. It
+// matches ErrLimitExceeded.
+type LimitError struct {
+	// Path locates the offending source value, such as "Items[3].Name"; map
+	// keys are written like field names. It is empty for the root value.
+	Path string
+	// Limit is "depth", "elements" or "string length".
+	Limit string
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "root"
+	}
+	return fmt.Sprintf("%s limit %d exceeded at %s", e.Limit, e.Max, path)
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+func (e *LimitError) prependPath(seg string) {
+	e.Path = joinPath(seg, e.Path)
+}
+
+// pathed 为记录出错位置的错误，容器的转换函数在错误返回时补上路径
+type pathed interface {
+	prependPath(seg string)
+}
+
+// errAtField、errAtIndex、errAtKey 为带路径的错误补上字段、下标或 map 键，其余错误原样返回
+func errAtField(err error, name string) error {
+	var p pathed
+	if errors.As(err, &p) {
+		p.prependPath(name)
+	}
+	return err
+}
+
+func errAtIndex(err error, i int) error {
+	var p pathed
+	if errors.As(err, &p) {
+		p.prependPath("[" + strconv.Itoa(i) + "]")
+	}
+	return err
+}
+
+func errAtKey(err error, key rt.Value) error {
+	var p pathed
+	if errors.As(err, &p) {
+		if key.Typ.Kind() == reflect.String {
+			p.prependPath(*(*string)(key.Ptr))
+		} else {
+			p.prependPath(fmt.Sprintf("[%v]", key.Typ.UnsafeIndirect(key.Ptr)))
+		}
+	}
+	return err
+}
+
+// newState 返回单次拷贝的状态，没有限制且 ctx 不会取消时为 nil
+func (c *Copier) newState(ctx context.Context) rt.State {
+	done := ctx.Done()
+	if c.limits == (Limits{}) && done == nil {
+		return nil
+	}
+	return &copyState{limits: c.limits, ctx: ctx, done: done, ticks: ctxCheckInterval}
+}
+
+// entersState 判断 v -> t 的拷贝前后是否需要调用 rt.State；
+// 目标为接口或指针时同一源值会再转换一次，只在那一次调用，直接存入接口的值由 enterLeaf 检查
+func entersState(v, t reflect2.Type) bool {
+	if k := t.Kind(); k == reflect.Interface || k == reflect.Ptr {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		return true
+	}
+	return false
+}
+
+// enterState 在单次拷贝状态的 Enter 与 Leave 之间执行 op
+func enterState(op ConvertFunc, v, t rt.Value) error {
+	if err := v.St.Enter(v); err != nil {
+		return err
+	}
+	err := op(v, t)
+	v.St.Leave(v)
+	return err
+}
+
+// enterLeaf 对直接存入接口、不再转换的字符串等值调用 rt.State，使其同样受长度限制与 ctx 检查
+func enterLeaf(v rt.Value) error {
+	if v.St == nil {
+		return nil
+	}
+	if err := v.St.Enter(v); err != nil {
+		return err
+	}
+	v.St.Leave(v)
+	return nil
+}
+
+// copyState 记录单次拷贝的嵌套深度与元素总数，并定期检查 ctx 是否已取消
+type copyState struct {
+	limits   Limits
+	depth    int
+	elements int
+
+	ctx  context.Context
+	done <-chan struct{}
+	// ticks 为上次检查 ctx 后进入的值及其元素个数
+	ticks int
+}
+
+func (st *copyState) Enter(v rt.Value) error {
+	if st.done != nil {
+		if err := st.checkContext(); err != nil {
+			return err
+		}
+	}
+	if st.limits == (Limits{}) {
+		st.depth++
+		return nil
+	}
+	var n int
+	switch v.Typ.Kind() {
+	case reflect.String:
+		if err := st.checkLen(len(*(*string)(v.Ptr))); err != nil {
+			return err
+		}
+		// 与容器相同计入深度，由 Leave 统一减去
+		st.depth++
+		return nil
+	case reflect.Slice:
+		n = v.Typ.(*reflect2.UnsafeSliceType).UnsafeLengthOf(v.Ptr)
+		if v.Typ.(reflect2.SliceType).Elem().Kind() == reflect.Uint8 {
+			// []byte 与 string 相同，只限制长度
+			if err := st.checkLen(n); err != nil {
+				return err
+			}
+			n = 0
+		}
+	case reflect.Array:
+		n = v.Typ.(reflect2.ArrayType).Len()
+	case reflect.Map:
+		n = reflect.NewAt(v.Typ.Type1(), v.Ptr).Elem().Len()
+	}
+	if max := st.limits.MaxDepth; max > 0 && st.depth >= max {
+		return &LimitError{Limit: "depth", Max: max}
+	}
+	if max := st.limits.MaxElements; max > 0 && st.elements+n > max {
+		return &LimitError{Limit: "elements", Max: max}
+	}
+	st.elements += n
+	st.depth++
+	return nil
+}
+
+func (st *copyState) Leave(v rt.Value) {
+	st.depth--
+}
+
+func (st *copyState) checkLen(n int) error {
+	if max := st.limits.MaxStringLen; max > 0 && n > max {
+		return &LimitError{Limit: "string length", Max: max}
+	}
+	return nil
+}
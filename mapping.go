@@ -0,0 +1,217 @@
+package go_deep_copy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// Mapping describes how Src structs are copied into Dst structs without
+// struct tags, for types that cannot be annotated such as generated or
+// third-party ones:
+//
+//	m := go_deep_copy.NewMapping[User, UserDTO]().
+//		Field("Dst.Name", "Src.FullName").
+//		Ignore("Dst.Secret").
+//		Convert("Dst.Age", func(age int64) (string, error) { ... })
+//	copier := go_deep_copy.NewCopier(go_deep_copy.WithMapping(m))
+//
+// Paths are dotted field names relative to the struct and may start with
+// "Src."/"Dst." or the type name. Fields the mapping does not mention are
+// matched by name as usual. Unknown paths and invalid convert funcs fail when
+// the plan is compiled, see Precompile.
+type Mapping[Src, Dst any] struct {
+	m *structMapping
+}
+
+// MappingProfile is a mapping that can be registered with WithMapping, see
+// NewMapping.
+type MappingProfile interface {
+	profile() *structMapping
+}
+
+// structMapping 与类型参数无关的映射配置，rules 按目标路径首次出现的顺序排列
+type structMapping struct {
+	from, to reflect2.Type
+	rules    []*mappingRule
+}
+
+// mappingRule 针对一个目标路径的映射规则
+type mappingRule struct {
+	dst     string
+	src     string
+	ignore  bool
+	convert reflect.Value
+}
+
+// NewMapping creates an empty mapping from Src to Dst.
+func NewMapping[Src, Dst any]() *Mapping[Src, Dst] {
+	return &Mapping[Src, Dst]{m: &structMapping{
+		from: reflect2.TypeOf((*Src)(nil)).(*reflect2.UnsafePtrType).Elem(),
+		to:   reflect2.TypeOf((*Dst)(nil)).(*reflect2.UnsafePtrType).Elem(),
+	}}
+}
+
+// Field copies the source field at src into the destination field at dst.
+func (m *Mapping[Src, Dst]) Field(dst, src string) *Mapping[Src, Dst] {
+	m.m.rule(dst).src = src
+	return m
+}
+
+// Ignore leaves the top-level destination field dst untouched.
+func (m *Mapping[Src, Dst]) Ignore(dst string) *Mapping[Src, Dst] {
+	m.m.rule(dst).ignore = true
+	return m
+}
+
+// Convert fills the destination field at dst with fn, a func(In) Out or
+// func(In) (Out, error). fn receives the source field mapped to dst with
+// Field, or found by name, or the whole Src value when there is none. Out is
+// converted into the field type with the usual rules.
+func (m *Mapping[Src, Dst]) Convert(dst string, fn interface{}) *Mapping[Src, Dst] {
+	m.m.rule(dst).convert = reflect.ValueOf(fn)
+	return m
+}
+
+func (m *Mapping[Src, Dst]) profile() *structMapping {
+	return m.m
+}
+
+func (m *structMapping) rule(dst string) *mappingRule {
+	for _, r := range m.rules {
+		if r.dst == dst {
+			return r
+		}
+	}
+	r := &mappingRule{dst: dst}
+	m.rules = append(m.rules, r)
+	return r
+}
+
+// WithMapping registers mappings on the Copier. They are consulted, in place
+// of tag names, when compiling the plans of their struct pairs; a later
+// mapping for the same pair replaces an earlier one.
+func WithMapping(mappings ...MappingProfile) Option {
+	return func(c *Copier) {
+		if c.mappings == nil {
+			c.mappings = map[[2]uintptr]*structMapping{}
+		}
+		for _, mapping := range mappings {
+			m := mapping.profile()
+			// 复制规则，登记后对 Mapping 的修改不影响 Copier
+			rules := make([]*mappingRule, len(m.rules))
+			for i, r := range m.rules {
+				rule := *r
+				rules[i] = &rule
+			}
+			c.mappings[[2]uintptr{m.from.RType(), m.to.RType()}] = &structMapping{from: m.from, to: m.to, rules: rules}
+		}
+	}
+}
+
+// resolveMappingPath 解析映射中的路径，路径可以 prefix（Src/Dst）或类型名开头
+func (c *Copier) resolveMappingPath(typ reflect2.Type, prefix, path string) (*fieldPath, *Binding) {
+	if p, root := c.resolvePath(typ, path); p != nil {
+		return p, root
+	}
+	for _, name := range []string{prefix, typ.Type1().Name()} {
+		if name != "" && strings.HasPrefix(path, name+".") {
+			if p, root := c.resolvePath(typ, path[len(name)+1:]); p != nil {
+				return p, root
+			}
+		}
+	}
+	return nil, nil
+}
+
+// applyMapping 按映射规则匹配字段，被匹配或忽略的字段记入 usedV、usedT
+func (c *Copier) applyMapping(m *structMapping, usedV, usedT map[*Binding]bool) ([]fieldMatch, error) {
+	v, t := m.from, m.to
+	matches := make([]fieldMatch, 0, len(m.rules))
+	for _, r := range m.rules {
+		tp, tRoot := c.resolveMappingPath(t, "Dst", r.dst)
+		if tp == nil {
+			return nil, fmt.Errorf("%w: %s has no field %q", ErrInvalidMapping, t.Type1(), r.dst)
+		}
+		// 只有一段的路径对应顶层字段，其标签选项仍然生效
+		topLevel := tp.name == tRoot.path.name
+		if r.ignore {
+			if !topLevel {
+				return nil, fmt.Errorf("%w: cannot ignore nested field %q", ErrInvalidMapping, r.dst)
+			}
+			usedT[tRoot] = true
+			continue
+		}
+		var (
+			vp    *fieldPath
+			vRoot *Binding
+		)
+		if r.src != "" {
+			vp, vRoot = c.resolveMappingPath(v, "Src", r.src)
+			if vp == nil {
+				return nil, fmt.Errorf("%w: %s has no field %q", ErrInvalidMapping, v.Type1(), r.src)
+			}
+		} else if vp, vRoot = c.resolvePath(v, tp.name); vp == nil {
+			// 没有同名源字段时 Convert 接收整个源结构体
+			vp = &fieldPath{typ: v}
+		}
+		match := fieldMatch{v: vp, t: tp, convert: r.convert}
+		if topLevel {
+			match.tBinding = tRoot
+		}
+		matches = append(matches, match)
+		usedT[tRoot] = true
+		if vRoot != nil {
+			usedV[vRoot] = true
+		}
+	}
+	return matches, nil
+}
+
+// convertOutType 返回映射中 Convert 函数的输出类型，函数无效时返回 nil
+func convertOutType(fn reflect.Value) reflect2.Type {
+	if !fn.IsValid() || fn.Kind() != reflect.Func || fn.Type().NumOut() == 0 {
+		return nil
+	}
+	return reflect2.Type2(fn.Type().Out(0))
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// mappingConvertOp 构建调用映射中 Convert 函数的 vType -> tType 转换函数
+func (c *Copier) mappingConvertOp(owner reflect2.Type, m fieldMatch) (ConvertFunc, error) {
+	fn, vType, tType := m.convert, m.v.typ, m.t.typ
+	ft := fn.Type()
+	if fn.Kind() != reflect.Func || fn.IsNil() || ft.NumIn() != 1 ||
+		ft.NumOut() == 0 || ft.NumOut() > 2 || (ft.NumOut() == 2 && ft.Out(1) != errorType) {
+		return nil, fmt.Errorf("%w: %s.%s: convert must be func(In) Out or func(In) (Out, error), got %s", ErrInvalidMapping, owner.Type1(), m.t.name, ft)
+	}
+	if !vType.Type1().AssignableTo(ft.In(0)) {
+		return nil, fmt.Errorf("%w: %s.%s: convert does not accept %s", ErrInvalidMapping, owner.Type1(), m.t.name, vType.Type1())
+	}
+	field := m.t.name
+	in := vType.Type1()
+	write := c.resultWriter(ft.Out(0), tType)
+	return func(v, t rt.Value) error {
+		results := fn.Call([]reflect.Value{reflect.NewAt(in, v.Ptr).Elem()})
+		if len(results) == 2 && !results[1].IsNil() {
+			return fmt.Errorf("convert of %s: %w", field, results[1].Interface().(error))
+		}
+		return write(results[0], t)
+	}, nil
+}
+
+// resultWriter 返回将 outType 类型的函数结果按常规规则拷贝到 tType 目标的函数，结果可能引用源值，同类型时也需深拷贝
+func (c *Copier) resultWriter(outType reflect.Type, tType reflect2.Type) func(out reflect.Value, t rt.Value) error {
+	out := reflect2.Type2(outType)
+	cvt := c.LoadConvertFunc(out, tType)
+	return func(result reflect.Value, t rt.Value) error {
+		outPtr := reflect.New(outType)
+		outPtr.Elem().Set(result)
+		return cvt(rt.Value{Ptr: unsafe.Pointer(outPtr.Pointer()), Typ: out}, t)
+	}
+}
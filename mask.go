@@ -0,0 +1,572 @@
+package go_deep_copy
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// CopyOption configures a single DeepCopyWithOptions call.
+type CopyOption func(o *copyOptions)
+
+type copyOptions struct {
+	only   []string
+	except []string
+}
+
+// OnlyPaths copies only the given destination paths, leaving every other
+// destination field untouched, like a protobuf FieldMask. Paths use dots for
+// fields and map keys and [*] for slice, array and map elements:
+//
+//	go_deep_copy.DeepCopyWithOptions(&src, &dst, go_deep_copy.OnlyPaths("Name", "Address.City", "Items[*].SKU"))
+//
+// Selecting a path copies its whole subtree.
+func OnlyPaths(paths ...string) CopyOption {
+	return func(o *copyOptions) {
+		o.only = append(o.only, paths...)
+	}
+}
+
+// ExceptPaths copies everything but the given destination paths, which are
+// left untouched. Paths have the same syntax as in OnlyPaths.
+func ExceptPaths(paths ...string) CopyOption {
+	return func(o *copyOptions) {
+		o.except = append(o.except, paths...)
+	}
+}
+
+// DeepCopyWithOptions deep copies with per call options, see
+// Copier.DeepCopyWithOptions.
+func DeepCopyWithOptions(fromValue interface{}, toValue interface{}, opts ...CopyOption) error {
+	return defaultCopier.DeepCopyWithOptions(fromValue, toValue, opts...)
+}
+
+// DeepCopyWithOptions deep copies like DeepCopy with per call options such as
+// OnlyPaths and ExceptPaths. Field names are matched against the destination
+// fields, by Go name or tag name. Excluded subtrees are never visited. Paths
+// naming unknown struct fields, or going through interface destinations or
+// values other than structs, maps, slices, arrays and pointers, fail with
+// ErrInvalidMask. The masked plans are compiled once per mask and cached on c,
+// within the limit of WithCacheLimit.
+func (c *Copier) DeepCopyWithOptions(fromValue interface{}, toValue interface{}, opts ...CopyOption) error {
+	var o copyOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.only == nil && o.except == nil {
+		return c.deepCopy(fromValue, toValue)
+	}
+	v, t, err := c.copyValues(context.Background(), fromValue, toValue)
+	if err != nil {
+		return err
+	}
+	m, err := c.loadMask(o)
+	if err != nil {
+		return err
+	}
+	if m.root.full {
+		// ExceptPaths 排除了整个值时无需拷贝
+		if m.except {
+			return nil
+		}
+		return c.LoadConvertFunc(v.Typ, t.Typ)(v, t)
+	}
+	cvtFunc, err := m.compile(c, v.Typ, t.Typ, m.root)
+	if err != nil {
+		return err
+	}
+	return cvtFunc(v, t)
+}
+
+// fieldMask 编译后的字段掩码，except 为 true 时 root 中的路径为排除的路径
+type fieldMask struct {
+	except bool
+	root   *maskNode
+	// ops 按 [源类型, 目标类型, 节点] 缓存编译的转换函数
+	ops sync.Map
+}
+
+// maskNode 字段掩码中的一段路径，full 表示路径在此结束，整个子树被选中或排除
+type maskNode struct {
+	path     string
+	full     bool
+	children map[string]*maskNode
+}
+
+type maskOpKey struct {
+	v, t uintptr
+	node *maskNode
+}
+
+type maskOpEntry struct {
+	cvtFunc ConvertFunc
+	err     error
+}
+
+// maskScope 字段掩码在某一层的选择，nil 表示不受掩码限制
+type maskScope struct {
+	m    *fieldMask
+	node *maskNode
+}
+
+// loadMask 返回 o 对应的字段掩码，相同的路径集合共用一份编译结果
+func (c *Copier) loadMask(o copyOptions) (*fieldMask, error) {
+	if o.only != nil && o.except != nil {
+		return nil, fmt.Errorf("%w: OnlyPaths and ExceptPaths cannot be combined", ErrInvalidMask)
+	}
+	paths := append([]string(nil), o.only...)
+	prefix := "only:"
+	if o.except != nil {
+		paths = append(paths, o.except...)
+		prefix = "except:"
+	}
+	sort.Strings(paths)
+	key := prefix + strings.Join(paths, ",")
+	if m, ok := c.cache.loadMask(key); ok {
+		return m, nil
+	}
+	m := &fieldMask{except: o.except != nil, root: &maskNode{}}
+	for _, path := range paths {
+		if err := m.root.add(path); err != nil {
+			return nil, err
+		}
+	}
+	return c.cache.loadOrStoreMask(key, m), nil
+}
+
+// add 将 path 拆分为字段名与 [*] 加入掩码树
+func (n *maskNode) add(path string) error {
+	var segments []string
+	for _, part := range strings.Split(path, ".") {
+		name := part
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			name = part[:i]
+			for rest := part[i:]; rest != ""; rest = rest[len("[*]"):] {
+				if !strings.HasPrefix(rest, "[*]") {
+					return fmt.Errorf("%w: %q: only [*] is supported inside brackets", ErrInvalidMask, path)
+				}
+				if name != "" {
+					segments = append(segments, name)
+				}
+				name = "[*]"
+			}
+		}
+		if name == "" {
+			return fmt.Errorf("%w: %q has an empty segment", ErrInvalidMask, path)
+		}
+		segments = append(segments, name)
+	}
+	cur := n
+	for _, segment := range segments {
+		if cur.full {
+			return nil
+		}
+		if cur.children == nil {
+			cur.children = map[string]*maskNode{}
+		}
+		next, ok := cur.children[segment]
+		if !ok {
+			next = &maskNode{path: joinPath(cur.path, segment)}
+			if segment == "[*]" {
+				next.path = cur.path + segment
+			}
+			cur.children[segment] = next
+		}
+		cur = next
+	}
+	// 选中或排除整个子树时不再需要更深的路径
+	cur.full, cur.children = true, nil
+	return nil
+}
+
+// pick 返回名为 names 之一的子项是否拷贝，next 不为 nil 时子项内部仍受掩码限制
+func (s *maskScope) pick(names ...string) (next *maskNode, ok bool) {
+	if s == nil {
+		return nil, true
+	}
+	var child *maskNode
+	for _, name := range names {
+		if child = s.node.children[name]; child != nil {
+			break
+		}
+	}
+	if s.m.except {
+		if child == nil {
+			return nil, true
+		}
+		if child.full {
+			return nil, false
+		}
+		return child, true
+	}
+	if child == nil {
+		return nil, false
+	}
+	if child.full {
+		return nil, true
+	}
+	return child, true
+}
+
+// maskedFunc 返回 v -> t 的转换函数，next 为 nil 时不受掩码限制
+func (c *Copier) maskedFunc(scope *maskScope, next *maskNode, v, t reflect2.Type) (ConvertFunc, error) {
+	if scope == nil || next == nil {
+		return c.LoadConvertFunc(v, t), nil
+	}
+	return scope.m.compile(c, v, t, next)
+}
+
+// compile 返回受节点 n 限制的 v -> t 转换函数
+func (m *fieldMask) compile(c *Copier, v, t reflect2.Type, n *maskNode) (ConvertFunc, error) {
+	key := maskOpKey{v: v.RType(), t: t.RType(), node: n}
+	if entry, ok := m.ops.Load(key); ok {
+		return entry.(maskOpEntry).cvtFunc, entry.(maskOpEntry).err
+	}
+	cvtFunc, err := c.maskOp(&maskScope{m: m, node: n}, v, t)
+	m.ops.Store(key, maskOpEntry{cvtFunc: cvtFunc, err: err})
+	return cvtFunc, err
+}
+
+func invalidMask(n *maskNode, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s: %s", ErrInvalidMask, n.path, fmt.Sprintf(format, args...))
+}
+
+// maskOp 按类型构建受字段掩码限制的转换函数，掩码只能深入结构体、map、切片、数组及指针
+func (c *Copier) maskOp(scope *maskScope, v, t reflect2.Type) (ConvertFunc, error) {
+	n := scope.node
+	if isNoCopyType(v) || isNoCopyType(t) {
+		return nil, invalidMask(n, "cannot select inside %s", t.Type1())
+	}
+	switch {
+	case v.Kind() == reflect.Ptr:
+		vElem := v.(*reflect2.UnsafePtrType).Elem()
+		elemOp, err := scope.m.compile(c, vElem, t, n)
+		if err != nil {
+			return nil, err
+		}
+		// 掩码只选中指针之下的路径，源指针为 nil 时不能整体替换目标，而是按零值只更新选中的字段
+		zero := vElem.UnsafeNew()
+		return func(v, t rt.Value) error {
+			ptr := *(*unsafe.Pointer)(v.Ptr)
+			if ptr == nil {
+				if t.Typ.Kind() == reflect.Ptr && *(*unsafe.Pointer)(t.Ptr) == nil {
+					return nil
+				}
+				ptr = zero
+			}
+			return elemOp(rt.Value{Ptr: ptr, Typ: vElem, St: v.St}, t)
+		}, nil
+	case v.Kind() == reflect.Interface:
+		// 动态类型在拷贝时才能确定
+		v1 := v.Type1()
+		return func(v, t rt.Value) error {
+			iface := reflect.NewAt(v1, v.Ptr).Elem()
+			if iface.IsNil() {
+				// 没有动态类型，无法确定选中的字段，目标保持不变
+				return nil
+			}
+			dyn := reflect.New(iface.Elem().Type())
+			dyn.Elem().Set(iface.Elem())
+			dynType := reflect2.Type2(dyn.Type().Elem())
+			elemOp, err := scope.m.compile(c, dynType, t.Typ, n)
+			if err != nil {
+				return err
+			}
+			return elemOp(rt.Value{Ptr: unsafe.Pointer(dyn.Pointer()), Typ: dynType, St: v.St}, t)
+		}, nil
+	case t.Kind() == reflect.Ptr:
+		tElem := t.(*reflect2.UnsafePtrType).Elem()
+		elemOp, err := scope.m.compile(c, v, tElem, n)
+		if err != nil {
+			return nil, err
+		}
+		return func(v, t rt.Value) error {
+			// 目标指针已有值时在其上部分更新
+			ptr := (*unsafe.Pointer)(t.Ptr)
+			if *ptr == nil {
+				*ptr = c.newValue(tElem)
+			}
+			return elemOp(v, rt.Value{Ptr: *ptr, Typ: tElem})
+		}, nil
+	}
+	vKind, tKind := getKind(v), getKind(t)
+	switch {
+	case vKind == reflect.Struct && tKind == reflect.Struct:
+		if err := c.maskFieldsError(scope, t); err != nil {
+			return nil, err
+		}
+		return c.structToStructOp(v, t, scope), nil
+	case vKind == reflect.Struct && tKind == reflect.Map:
+		return c.structToMapMaskOp(scope, v, t)
+	case vKind == reflect.Map && tKind == reflect.Struct:
+		if v.(reflect2.MapType).Key().Kind() != reflect.String {
+			return nil, invalidMask(n, "cannot select fields from %s", v.Type1())
+		}
+		if err := c.maskFieldsError(scope, t); err != nil {
+			return nil, err
+		}
+		return c.mapToStructOp(v, t, scope), nil
+	case vKind == reflect.Map && tKind == reflect.Map:
+		return c.mapMaskOp(scope, v, t)
+	case (vKind == reflect.Slice || vKind == reflect.Array) && (tKind == reflect.Slice || tKind == reflect.Array):
+		return c.seqMaskOp(scope, v, t)
+	}
+	return nil, invalidMask(n, "cannot select inside %s", t.Type1())
+}
+
+// maskFieldsError 检查掩码中的名称是否均为 typ 的字段
+func (c *Copier) maskFieldsError(scope *maskScope, typ reflect2.Type) error {
+	info := c.loadStructFieldsInfo(typ)
+	for name, child := range scope.node.children {
+		if _, ok := info.FieldMap[name]; ok {
+			continue
+		}
+		found := false
+		for _, b := range info.Fields {
+			if b.Field.Name() == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return invalidMask(child, "%s has no field %q", typ.Type1(), name)
+		}
+	}
+	return nil
+}
+
+// maskFieldPlans 按字段掩码筛选 struct -> struct 的字段计划，需要继续深入的字段改用受掩码限制的转换函数
+func (c *Copier) maskFieldPlans(scope *maskScope, t reflect2.Type, fields, defaults []fieldPlan) ([]fieldPlan, []fieldPlan, error) {
+	kept := make([]fieldPlan, 0, len(fields))
+	for _, f := range fields {
+		next, ok := scope.pick(f.root, f.name)
+		if !ok {
+			continue
+		}
+		if next != nil {
+			if f.custom {
+				return nil, nil, invalidMask(next, "cannot select inside a converted field")
+			}
+			cvtFunc, err := scope.m.compile(c, f.vType, f.tType, next)
+			if err != nil {
+				return nil, nil, err
+			}
+			f.cvtFunc = cvtFunc
+		}
+		kept = append(kept, f)
+	}
+	var keptDefaults []fieldPlan
+	for _, f := range defaults {
+		if _, ok := scope.pick(f.root, f.name); ok {
+			keptDefaults = append(keptDefaults, f)
+		}
+	}
+	return kept, keptDefaults, nil
+}
+
+// structToMapMaskOp 返回受字段掩码限制的 struct -> map 转换函数
+func (c *Copier) structToMapMaskOp(scope *maskScope, v, t reflect2.Type) (ConvertFunc, error) {
+	tType := t.(*reflect2.UnsafeMapType)
+	if tType.Key().Kind() != reflect.String {
+		return nil, invalidMask(scope.node, "cannot select keys of %s", t.Type1())
+	}
+	if err := c.maskFieldsError(scope, v); err != nil {
+		return nil, err
+	}
+	tElemType := tType.Elem()
+	type entry struct {
+		b       *Binding
+		cvtFunc ConvertFunc
+	}
+	var entries []entry
+	for _, b := range c.loadStructFieldsInfo(v).Fields {
+		next, ok := scope.pick(b.Field.Name(), b.Name)
+		if !ok {
+			continue
+		}
+		cvtFunc, err := c.maskedFunc(scope, next, b.Field.Type(), tElemType)
+		if err != nil {
+			return nil, err
+		}
+		if c.redacts(b.sensitive) {
+			if c.redaction == RedactDrop {
+				continue
+			}
+			cvtFunc = c.redactOp(b.Field.Type(), tElemType)
+		}
+		entries = append(entries, entry{b: b, cvtFunc: cvtFunc})
+	}
+	return func(v, t rt.Value) error {
+		if tType.UnsafeIsNil(t.Ptr) {
+			tType.UnsafeSet(t.Ptr, tType.UnsafeMakeMap(0))
+		}
+		for _, e := range entries {
+			vPtr := e.b.path.read(v.Ptr)
+			if vPtr == nil {
+				continue
+			}
+			name := e.b.Field.Name()
+			tElem := tElemType.UnsafeNew()
+			err := e.cvtFunc(rt.Value{Ptr: vPtr, Typ: e.b.Field.Type(), St: v.St}, rt.Value{Ptr: tElem, Typ: tElemType})
+			if err != nil {
+				return errAtField(err, e.b.Name)
+			}
+			tType.UnsafeSetIndex(t.Ptr, unsafe.Pointer(&name), tElem)
+		}
+		return nil
+	}, nil
+}
+
+// mapMaskOp 返回受字段掩码限制的 map -> map 转换函数，字符串键可按名称选择，[*] 匹配所有键
+func (c *Copier) mapMaskOp(scope *maskScope, v, t reflect2.Type) (ConvertFunc, error) {
+	vType, tType := v.(*reflect2.UnsafeMapType), t.(*reflect2.UnsafeMapType)
+	vKType, tKType := vType.Key(), tType.Key()
+	vElemType, tElemType := vType.Elem(), tType.Elem()
+	stringKeys := vKType.Kind() == reflect.String
+	type entry struct {
+		ok      bool
+		cvtFunc ConvertFunc
+	}
+	pick := func(names ...string) (entry, error) {
+		next, ok := scope.pick(names...)
+		if !ok {
+			return entry{}, nil
+		}
+		cvtFunc, err := c.maskedFunc(scope, next, vElemType, tElemType)
+		return entry{ok: true, cvtFunc: cvtFunc}, err
+	}
+	named := map[string]entry{}
+	for name, child := range scope.node.children {
+		if name == "[*]" {
+			continue
+		}
+		if !stringKeys {
+			return nil, invalidMask(child, "cannot select keys of %s", v.Type1())
+		}
+		e, err := pick(name)
+		if err != nil {
+			return nil, err
+		}
+		named[name] = e
+	}
+	wildcard, err := pick("[*]")
+	if err != nil {
+		return nil, err
+	}
+	keyConverter := c.LoadConvertFunc(vKType, tKType)
+	return func(v, t rt.Value) error {
+		if vType.UnsafeIsNil(v.Ptr) {
+			tType.UnsafeSet(t.Ptr, tType.UnsafeNew())
+			return nil
+		}
+		if tType.UnsafeIsNil(t.Ptr) {
+			tType.UnsafeSet(t.Ptr, tType.UnsafeMakeMap(0))
+		}
+		iter := vType.UnsafeIterate(v.Ptr)
+		for iter.HasNext() {
+			if err := tick(v.St); err != nil {
+				return err
+			}
+			vKey, vElem := iter.UnsafeNext()
+			e := wildcard
+			if stringKeys {
+				if ne, ok := named[*(*string)(vKey)]; ok {
+					e = ne
+				}
+			}
+			if !e.ok {
+				continue
+			}
+			tKey := tKType.UnsafeNew()
+			tElem := tElemType.UnsafeNew()
+			key := rt.Value{Ptr: vKey, Typ: vKType, St: v.St}
+			if err := keyConverter(key, rt.Value{Ptr: tKey, Typ: tKType}); err != nil {
+				return errAtKey(err, key)
+			}
+			if err := e.cvtFunc(rt.Value{Ptr: vElem, Typ: vElemType, St: v.St}, rt.Value{Ptr: tElem, Typ: tElemType}); err != nil {
+				return errAtKey(err, key)
+			}
+			tType.UnsafeSetIndex(t.Ptr, tKey, tElem)
+		}
+		return nil
+	}, nil
+}
+
+// seqMaskOp 返回受字段掩码限制的切片、数组之间的转换函数，元素只能以 [*] 选择；
+// 目标切片中已有的元素在其上部分更新
+func (c *Copier) seqMaskOp(scope *maskScope, v, t reflect2.Type) (ConvertFunc, error) {
+	for name, child := range scope.node.children {
+		if name != "[*]" {
+			return nil, invalidMask(child, "elements of %s can only be selected with [*]", v.Type1())
+		}
+	}
+	next, ok := scope.pick("[*]")
+	if !ok {
+		return func(v, t rt.Value) error {
+			return nil
+		}, nil
+	}
+	vElemType, tElemType := elemOf(v), elemOf(t)
+	elemOp, err := c.maskedFunc(scope, next, vElemType, tElemType)
+	if err != nil {
+		return nil, err
+	}
+	vSlice, vIsSlice := v.(*reflect2.UnsafeSliceType)
+	vArray, _ := v.(*reflect2.UnsafeArrayType)
+	tSlice, tIsSlice := t.(*reflect2.UnsafeSliceType)
+	tArray, _ := t.(*reflect2.UnsafeArrayType)
+	return func(v, t rt.Value) error {
+		length := 0
+		if vIsSlice {
+			if vSlice.UnsafeIsNil(v.Ptr) {
+				if tIsSlice {
+					tSlice.UnsafeSetNil(t.Ptr)
+				}
+				return nil
+			}
+			length = vSlice.UnsafeLengthOf(v.Ptr)
+		} else {
+			length = vArray.Len()
+		}
+		tPtr := t.Ptr
+		if tIsSlice {
+			tPtr = c.makeSlice(tSlice, length)
+			old := tSlice.UnsafeLengthOf(t.Ptr)
+			for i := 0; i < old && i < length; i++ {
+				tElemType.UnsafeSet(tSlice.UnsafeGetIndex(tPtr, i), tSlice.UnsafeGetIndex(t.Ptr, i))
+			}
+		} else if length > tArray.Len() {
+			length = tArray.Len()
+		}
+		for i := 0; i < length; i++ {
+			if err := tick(v.St); err != nil {
+				return errAtIndex(err, i)
+			}
+			var vElem, tElem unsafe.Pointer
+			if vIsSlice {
+				vElem = vSlice.UnsafeGetIndex(v.Ptr, i)
+			} else {
+				vElem = vArray.UnsafeGetIndex(v.Ptr, i)
+			}
+			if tIsSlice {
+				tElem = tSlice.UnsafeGetIndex(tPtr, i)
+			} else {
+				tElem = tArray.UnsafeGetIndex(tPtr, i)
+			}
+			if err := elemOp(rt.Value{Ptr: vElem, Typ: vElemType, St: v.St}, rt.Value{Ptr: tElem, Typ: tElemType}); err != nil {
+				return errAtIndex(err, i)
+			}
+		}
+		if tIsSlice {
+			tSlice.UnsafeSet(t.Ptr, tPtr)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,126 @@
+package go_deep_copy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// WithMethods maps struct fields through methods during struct to struct
+// copies. A destination field no source field maps to is filled from the
+// source method of the same name taking no argument and returning the value,
+// optionally with an error:
+//
+//	func (u User) DoubleAge() int32 // fills Employee.DoubleAge
+//
+// A source field no destination field maps to is passed to the destination
+// method SetX or X, where X is the field name, taking the value and
+// returning nothing or an error:
+//
+//	func (e *Employee) Role(role string) // receives User.Role
+//
+// Methods of the value and of the pointer types are both considered; fields
+// of embedded structs are not mapped through methods. Setters run after the
+// fields are copied.
+func WithMethods() Option {
+	return func(c *Copier) {
+		c.methods = true
+	}
+}
+
+// getterOf 返回 typ 上名为 name、可作为取值方法的方法，方法的接收者为 *typ
+func getterOf(typ reflect2.Type, name string) (reflect.Value, bool) {
+	m, ok := reflect.PtrTo(typ.Type1()).MethodByName(name)
+	if !ok {
+		return reflect.Value{}, false
+	}
+	ft := m.Type
+	if ft.NumIn() != 1 || ft.NumOut() == 0 || ft.NumOut() > 2 || (ft.NumOut() == 2 && ft.Out(1) != errorType) {
+		return reflect.Value{}, false
+	}
+	return m.Func, true
+}
+
+// setterOf 返回 typ 上字段 name 的赋值方法 SetName 或 Name，方法的接收者为 *typ
+func setterOf(typ reflect2.Type, name string) (reflect.Value, bool) {
+	ptrType := reflect.PtrTo(typ.Type1())
+	for _, methodName := range []string{"Set" + name, name} {
+		m, ok := ptrType.MethodByName(methodName)
+		if !ok {
+			continue
+		}
+		ft := m.Type
+		if ft.NumIn() == 2 && (ft.NumOut() == 0 || ft.NumOut() == 1 && ft.Out(0) == errorType) {
+			return m.Func, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// matchMethods 为未匹配的目标字段查找源结构体的取值方法，为未匹配的源字段查找目标结构体的赋值方法
+func (c *Copier) matchMethods(v, t reflect2.Type, vFields, tFields []*Binding, usedV, usedT map[*Binding]bool) []fieldMatch {
+	var matches []fieldMatch
+	for _, tf := range tFields {
+		if usedT[tf] || len(tf.path.hops) > 0 {
+			continue
+		}
+		name := tf.Field.Name()
+		if getter, ok := getterOf(v, name); ok {
+			matches = append(matches, fieldMatch{v: &fieldPath{name: name + "()", typ: v}, t: tf.path, tBinding: tf, getter: getter})
+			usedT[tf] = true
+		}
+	}
+	for _, f := range vFields {
+		if usedV[f] || len(f.path.hops) > 0 {
+			continue
+		}
+		name := f.Field.Name()
+		if setter, ok := setterOf(t, name); ok {
+			matches = append(matches, fieldMatch{v: f.path, t: &fieldPath{name: name + "()", typ: t}, setter: setter})
+			usedV[f] = true
+		}
+	}
+	return matches
+}
+
+// getterOp 构建调用源结构体取值方法并写入目标字段的转换函数，v 为整个源结构体
+func (c *Copier) getterOp(m fieldMatch) ConvertFunc {
+	ft := m.getter.Type()
+	recv := m.v.typ.Type1()
+	method := strings.TrimSuffix(m.v.name, "()")
+	write := c.resultWriter(ft.Out(0), m.t.typ)
+	return func(v, t rt.Value) error {
+		results := m.getter.Call([]reflect.Value{reflect.NewAt(recv, v.Ptr)})
+		if len(results) == 2 && !results[1].IsNil() {
+			return fmt.Errorf("%s.%s: %w", recv, method, results[1].Interface().(error))
+		}
+		return write(results[0], t)
+	}
+}
+
+// setterOp 构建将源字段转换为参数类型后调用目标结构体赋值方法的转换函数，t 为整个目标结构体
+func (c *Copier) setterOp(m fieldMatch) ConvertFunc {
+	ft := m.setter.Type()
+	recv := m.t.typ.Type1()
+	method := strings.TrimSuffix(m.t.name, "()")
+	vType := m.v.typ
+	argType := ft.In(1)
+	arg := reflect2.Type2(argType)
+	// 源字段先按常规规则拷贝为参数，赋值方法拿到的值不与源共享
+	cvt := c.LoadConvertFunc(vType, arg)
+	return func(v, t rt.Value) error {
+		in := reflect.New(argType)
+		if err := cvt(v, rt.Value{Ptr: unsafe.Pointer(in.Pointer()), Typ: arg}); err != nil {
+			return err
+		}
+		results := m.setter.Call([]reflect.Value{reflect.NewAt(recv, t.Ptr), in.Elem()})
+		if len(results) == 1 && !results[0].IsNil() {
+			return fmt.Errorf("%s.%s: %w", recv, method, results[0].Interface().(error))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,182 @@
+package go_deep_copy
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// NoCopyPolicy decides what happens to values that must not be copied,
+// such as sync.Mutex, sync.WaitGroup, sync.Once, atomic.Value or any type
+// carrying a noCopy marker.
+type NoCopyPolicy int
+
+const (
+	// NoCopyZero leaves the destination zeroed; atomic types are loaded
+	// atomically from the source and stored into the destination.
+	NoCopyZero NoCopyPolicy = iota
+	// NoCopyError fails the copy with ErrNoCopy.
+	NoCopyError
+)
+
+// WithNoCopyPolicy sets how the Copier handles no-copy types, NoCopyZero by default.
+func WithNoCopyPolicy(policy NoCopyPolicy) Option {
+	return func(c *Copier) {
+		c.noCopyPolicy = policy
+	}
+}
+
+// isNoCopyType reports whether values of typ must not be copied by value,
+// i.e. structs from sync or sync/atomic and structs declaring a noCopy field.
+func isNoCopyType(typ reflect2.Type) bool {
+	t := typ.Type1()
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	if pkg := t.PkgPath(); pkg == "sync" || pkg == "sync/atomic" {
+		return true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type.Name() == "noCopy" {
+			return true
+		}
+	}
+	return false
+}
+
+// atomicField 描述 sync/atomic 类型中实际承载数据的字段 v
+type atomicField struct {
+	name   string
+	offset uintptr
+	kind   reflect.Kind
+	// 原子类型对外表现的值类型，如 atomic.Bool 为 bool，atomic.Pointer[T] 为 *T
+	typ reflect2.Type
+}
+
+func atomicFieldOf(typ reflect2.Type) (atomicField, bool) {
+	t := typ.Type1()
+	if t.Kind() != reflect.Struct || t.PkgPath() != "sync/atomic" {
+		return atomicField{}, false
+	}
+	v, ok := t.FieldByName("v")
+	if !ok {
+		return atomicField{}, false
+	}
+	f := atomicField{name: t.Name(), offset: v.Offset, kind: v.Type.Kind(), typ: reflect2.Type2(v.Type)}
+	switch {
+	case f.name == "Bool":
+		f.typ = reflect2.TypeOf(false)
+	case f.kind == reflect.UnsafePointer:
+		// atomic.Pointer[T] 通过 _ [0]*T 记录元素类型
+		f.typ = reflect2.Type2(t.Field(0).Type.Elem())
+	}
+	return f, true
+}
+
+// load 原子读取 ptr 处的值，返回指向 f.typ 类型副本的指针
+func (f atomicField) load(ptr unsafe.Pointer) unsafe.Pointer {
+	out := f.typ.UnsafeNew()
+	vPtr := pointerOffset(ptr, f.offset)
+	switch f.kind {
+	case reflect.Int32:
+		*(*int32)(out) = atomic.LoadInt32((*int32)(vPtr))
+	case reflect.Int64:
+		*(*int64)(out) = atomic.LoadInt64((*int64)(vPtr))
+	case reflect.Uint32:
+		if f.name == "Bool" {
+			*(*bool)(out) = atomic.LoadUint32((*uint32)(vPtr)) != 0
+		} else {
+			*(*uint32)(out) = atomic.LoadUint32((*uint32)(vPtr))
+		}
+	case reflect.Uint64:
+		*(*uint64)(out) = atomic.LoadUint64((*uint64)(vPtr))
+	case reflect.Uintptr:
+		*(*uintptr)(out) = atomic.LoadUintptr((*uintptr)(vPtr))
+	case reflect.UnsafePointer:
+		*(*unsafe.Pointer)(out) = atomic.LoadPointer((*unsafe.Pointer)(vPtr))
+	case reflect.Interface:
+		*(*interface{})(out) = (*atomic.Value)(ptr).Load()
+	}
+	return out
+}
+
+// store 将 val 指向的 f.typ 类型值原子写入 ptr
+func (f atomicField) store(ptr unsafe.Pointer, val unsafe.Pointer) {
+	vPtr := pointerOffset(ptr, f.offset)
+	switch f.kind {
+	case reflect.Int32:
+		atomic.StoreInt32((*int32)(vPtr), *(*int32)(val))
+	case reflect.Int64:
+		atomic.StoreInt64((*int64)(vPtr), *(*int64)(val))
+	case reflect.Uint32:
+		if f.name == "Bool" {
+			var b uint32
+			if *(*bool)(val) {
+				b = 1
+			}
+			atomic.StoreUint32((*uint32)(vPtr), b)
+		} else {
+			atomic.StoreUint32((*uint32)(vPtr), *(*uint32)(val))
+		}
+	case reflect.Uint64:
+		atomic.StoreUint64((*uint64)(vPtr), *(*uint64)(val))
+	case reflect.Uintptr:
+		atomic.StoreUintptr((*uintptr)(vPtr), *(*uintptr)(val))
+	case reflect.UnsafePointer:
+		atomic.StorePointer((*unsafe.Pointer)(vPtr), *(*unsafe.Pointer)(val))
+	case reflect.Interface:
+		// 目标中已有值的动态类型可能与 x 不同，atomic.Value 会因此 panic，改为写入新的 atomic.Value；
+		// atomic.Value 不接受 nil，源为空时目标同样为空
+		var fresh atomic.Value
+		if x := *(*interface{})(val); x != nil {
+			fresh.Store(x)
+		}
+		*(*atomic.Value)(ptr) = fresh
+	}
+}
+
+// convertOp: no-copy type -> T / T -> no-copy type
+func (c *Copier) noCopyOp(v, t reflect2.Type) func(v, t rt.Value) error {
+	if c.noCopyPolicy == NoCopyError {
+		err := fmt.Errorf("%w: %s -> %s", ErrNoCopy, v.String(), t.String())
+		return func(v, t rt.Value) error {
+			return err
+		}
+	}
+	vAtomic, vIsAtomic := atomicFieldOf(v)
+	tAtomic, tIsAtomic := atomicFieldOf(t)
+	if isNoCopyType(v) && !vIsAtomic || isNoCopyType(t) && !tIsAtomic {
+		// 锁、WaitGroup、Once 等不复制状态，目标保持零值
+		zero := t.UnsafeNew()
+		return func(v, t rt.Value) error {
+			t.Typ.UnsafeSet(t.Ptr, zero)
+			return nil
+		}
+	}
+	vType, tType := v, t
+	if vIsAtomic {
+		vType = vAtomic.typ
+	}
+	if tIsAtomic {
+		tType = tAtomic.typ
+	}
+	cvtFunc := c.loadEdgeFunc("", "", vType, tType)
+	return func(v, t rt.Value) error {
+		if vIsAtomic {
+			v = rt.Value{Typ: vType, Ptr: vAtomic.load(v.Ptr), St: v.St}
+		}
+		if !tIsAtomic {
+			return cvtFunc(v, t)
+		}
+		tValue := rt.Value{Typ: tType, Ptr: tType.UnsafeNew()}
+		if err := cvtFunc(v, tValue); err != nil {
+			return err
+		}
+		tAtomic.store(t.Ptr, tValue.Ptr)
+		return nil
+	}
+}
@@ -0,0 +1,135 @@
+package go_deep_copy
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+)
+
+// DefaultParallelThreshold is the threshold used by WithParallel when it is
+// given a threshold below 1.
+const DefaultParallelThreshold = 1024
+
+// WithParallel makes the Copier copy slices of at least threshold elements
+// with up to workers goroutines, each converting a contiguous range of the
+// destination. workers below 1 means runtime.GOMAXPROCS(0). The goroutines are
+// shared by all copies of the Copier, so nested slices do not multiply them.
+//
+// A failing copy returns the error of the lowest failing index, like a
+// sequential copy would. Convert funcs from mappings and registered converters
+// must then be safe for concurrent use. Maps, and copies with limits, are
+// copied sequentially; with DeepCopyContext each goroutine checks the context.
+func WithParallel(workers, threshold int) Option {
+	return func(c *Copier) {
+		if workers < 1 {
+			workers = runtime.GOMAXPROCS(0)
+		}
+		if threshold < 1 {
+			threshold = DefaultParallelThreshold
+		}
+		if workers == 1 {
+			c.parallel = nil
+			return
+		}
+		c.parallel = &parallelism{
+			workers:   workers,
+			threshold: threshold,
+			// 发起拷贝的 goroutine 本身也参与转换
+			sem: make(chan struct{}, workers-1),
+		}
+	}
+}
+
+// parallelism 为并行拷贝切片的配置，sem 限制同时运行的 goroutine 个数
+type parallelism struct {
+	workers   int
+	threshold int
+	sem       chan struct{}
+}
+
+// parallelState 返回并行拷贝时为每段创建状态的函数；限制需要在整个拷贝中累计，带限制的状态不能分段，返回 false
+func parallelState(st rt.State) (func() rt.State, bool) {
+	if st == nil {
+		return func() rt.State { return nil }, true
+	}
+	cs, ok := st.(*copyState)
+	if !ok || cs.limits != (Limits{}) {
+		return nil, false
+	}
+	// 只检查 ctx 的状态各段各用一份，不必同步
+	return func() rt.State {
+		return &copyState{ctx: cs.ctx, done: cs.done, ticks: ctxCheckInterval}
+	}, true
+}
+
+// run 将 [0, n) 分段并行执行 copyElem，每段使用 newState 返回的状态，返回下标最小的错误；
+// 没有空闲的 goroutine 时由当前 goroutine 执行该段
+func (p *parallelism) run(n int, newState func() rt.State, copyElem func(i int, st rt.State) error) error {
+	chunks := p.workers
+	if chunks > n {
+		chunks = n
+	}
+	size := (n + chunks - 1) / chunks
+	var (
+		wg sync.WaitGroup
+		// failed 为目前出错的最小下标，更大下标的元素不必再拷贝
+		failed = int64(n)
+		errs   = make([]error, chunks)
+		at     = make([]int, chunks)
+	)
+	work := func(k int) {
+		end := (k + 1) * size
+		if end > n {
+			end = n
+		}
+		st := newState()
+		for i := k * size; i < end; i++ {
+			if int64(i) > atomic.LoadInt64(&failed) {
+				return
+			}
+			err := tick(st)
+			if err == nil {
+				err = copyElem(i, st)
+			}
+			if err != nil {
+				errs[k], at[k] = err, i
+				for {
+					cur := atomic.LoadInt64(&failed)
+					if int64(i) >= cur || atomic.CompareAndSwapInt64(&failed, cur, int64(i)) {
+						break
+					}
+				}
+				return
+			}
+		}
+	}
+	inline := []int{0}
+	for k := 1; k < chunks; k++ {
+		select {
+		case p.sem <- struct{}{}:
+			wg.Add(1)
+			go func(k int) {
+				defer func() {
+					<-p.sem
+					wg.Done()
+				}()
+				work(k)
+			}(k)
+		default:
+			inline = append(inline, k)
+		}
+	}
+	for _, k := range inline {
+		work(k)
+	}
+	wg.Wait()
+	// 各段按下标排列，第一个出错的段即为下标最小的错误
+	for k, err := range errs {
+		if err != nil {
+			return errAtIndex(err, at[k])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package go_deep_copy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// TypePair identifies the conversion of From values into To values.
+type TypePair struct {
+	From reflect.Type
+	To   reflect.Type
+}
+
+// PairOf returns the TypePair converting From into To.
+func PairOf[From, To any]() TypePair {
+	return TypePair{
+		From: reflect.TypeOf((*From)(nil)).Elem(),
+		To:   reflect.TypeOf((*To)(nil)).Elem(),
+	}
+}
+
+// PlanError reports a conversion inside a plan that can not succeed.
+// Path locates the field, "Items[*].SKU" for instance; it is empty for the root pair.
+type PlanError struct {
+	Path string
+	From reflect.Type
+	To   reflect.Type
+	Err  error
+}
+
+func (e *PlanError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s -> %s: %v", e.From, e.To, e.Err)
+	}
+	return fmt.Sprintf("%s: %s -> %s: %v", e.Path, e.From, e.To, e.Err)
+}
+
+func (e *PlanError) Unwrap() error {
+	return e.Err
+}
+
+// PlanErrors lists every failing conversion found by Precompile.
+type PlanErrors []*PlanError
+
+func (e PlanErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e PlanErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Precompile builds the plans of DeepCopy for the given pairs, see Copier.Precompile.
+func Precompile(pairs ...TypePair) error {
+	return defaultCopier.Precompile(pairs...)
+}
+
+// Warm builds the plan of DeepCopy from From to To, see Copier.Precompile.
+func Warm[From, To any]() error {
+	return defaultCopier.Precompile(PairOf[From, To]())
+}
+
+// Precompile builds and caches the convert funcs of every pair together with
+// all the nested field, element and key conversions they need, so that the
+// first copy does not pay for it. The conversions that would fail whatever the
+// values are returned as PlanErrors; types only known at run time, such as the
+// dynamic types held by interface{} sources, are not checked.
+func (c *Copier) Precompile(pairs ...TypePair) error {
+	w := &planWalker{c: c, seen: map[[2]uintptr]bool{}}
+	for _, pair := range pairs {
+		w.walk("", "", reflect2.Type2(pair.From), reflect2.Type2(pair.To))
+	}
+	if len(w.errs) > 0 {
+		return w.errs
+	}
+	return nil
+}
+
+// planEdge 转换计划中的一次子转换，vPath、tPath 分别为源与目标相对父转换的路径
+type planEdge struct {
+	vPath, tPath string
+	v, t         reflect2.Type
+}
+
+// planWalker 沿 planEdges 记录的子转换遍历转换计划树
+type planWalker struct {
+	c *Copier
+	// Precompile 中每个类型对只访问一次；Check 需要每条路径的结果，只跳过递归中的祖先
+	seen    map[[2]uintptr]bool
+	perPath bool
+	errs    PlanErrors
+	report  *Report
+}
+
+func (w *planWalker) walk(vPath, tPath string, v, t reflect2.Type) {
+	key := [2]uintptr{v.RType(), t.RType()}
+	if w.seen[key] {
+		return
+	}
+	w.seen[key] = true
+	if w.perPath {
+		defer delete(w.seen, key)
+	}
+	w.c.LoadConvertFunc(v, t)
+	if err := w.c.planError(v, t); err != nil {
+		w.errs = append(w.errs, &PlanError{Path: tPath, From: v.Type1(), To: t.Type1(), Err: err})
+		return
+	}
+	if w.report != nil {
+		w.report.add(w.c, vPath, tPath, v, t)
+	}
+	for _, edge := range w.c.planEdges(v, t) {
+		w.walk(joinPath(vPath, edge.vPath), joinPath(tPath, edge.tPath), edge.v, edge.t)
+	}
+}
+
+func joinPath(path, child string) string {
+	if path == "" || child == "" || child[0] == '[' || child[0] == '(' {
+		return path + child
+	}
+	return path + "." + child
+}
+
+// planError 返回 v -> t 与取值无关、必然失败的原因
+func (c *Copier) planError(v, t reflect2.Type) error {
+	if isNoCopyType(v) || isNoCopyType(t) {
+		if c.noCopyPolicy == NoCopyError {
+			return ErrNoCopy
+		}
+		return nil
+	}
+	if t.Kind() == reflect.Interface && t.Type1().NumMethod() > 0 {
+		if v.Kind() == reflect.Interface || c.implementationsOf(v, t) != nil {
+			return nil
+		}
+		t1 := t.Type1()
+		if !v.Type1().Implements(t1) && !reflect.PtrTo(v.Type1()).Implements(t1) {
+			return &InterfaceError{Type: v.Type1(), Interface: t1}
+		}
+		return nil
+	}
+	switch {
+	case v.Kind() == reflect.String && t.Kind() == reflect.Slice:
+		if !isBytesOrRunes(t) {
+			return ErrNotSupported
+		}
+	case v.Kind() == reflect.Slice && t.Kind() == reflect.String:
+		if !isBytesOrRunes(v) {
+			return ErrNotSupported
+		}
+	}
+	switch {
+	case v.Kind() == reflect.Struct && t.Kind() == reflect.Struct:
+		if _, _, err := c.structFieldPlans(v, t); err != nil {
+			return err
+		}
+	case v.Kind() == reflect.Map && t.Kind() == reflect.Struct:
+		if err := c.defaultsError(c.loadStructFieldsInfo(t)); err != nil {
+			return err
+		}
+	}
+	if c.convertOp(v, t) == nil {
+		return ErrNotSupported
+	}
+	return nil
+}
+
+func isBytesOrRunes(typ reflect2.Type) bool {
+	kind := typ.(reflect2.SliceType).Elem().Kind()
+	return kind == reflect.Uint8 || kind == reflect.Int32
+}
+
+// implementationsOf 返回 map -> 已登记接口时使用的实现登记
+func (c *Copier) implementationsOf(v, t reflect2.Type) *implementations {
+	if v.Kind() != reflect.Map || v.(reflect2.MapType).Key().Kind() != reflect.String {
+		return nil
+	}
+	return loadImplementations(t.Type1())
+}
+
+// planVisitor 记录编译转换函数时其依赖的子转换
+type planVisitor struct {
+	// c 为实际编译并缓存子转换函数的 Copier
+	c     *Copier
+	edges []planEdge
+}
+
+// planEdges 返回 v -> t 在拷贝时会用到的子转换：用带 visitor 的副本重新编译 v -> t，
+// 子转换由 convertOp 构建转换函数时通过 visitEdge、loadEdgeFunc 报告，编译结果丢弃
+func (c *Copier) planEdges(v, t reflect2.Type) []planEdge {
+	vc := *c
+	vc.visitor = &planVisitor{c: c}
+	vc.convertOp(v, t)
+	return vc.visitor.edges
+}
+
+// visitEdge 向 visitor 报告转换函数在拷贝时加载的子转换，不在记录时什么也不做
+func (c *Copier) visitEdge(vPath, tPath string, v, t reflect2.Type) {
+	if c.visitor != nil {
+		c.visitor.edges = append(c.visitor.edges, planEdge{vPath: vPath, tPath: tPath, v: v, t: t})
+	}
+}
+
+// loadEdgeFunc 报告并加载构建转换函数时即需要的子转换
+func (c *Copier) loadEdgeFunc(vPath, tPath string, v, t reflect2.Type) ConvertFunc {
+	c.visitEdge(vPath, tPath, v, t)
+	return c.LoadConvertFunc(v, t)
+}
+
+func elemOf(typ reflect2.Type) reflect2.Type {
+	if typ.Kind() == reflect.Array {
+		return typ.(reflect2.ArrayType).Elem()
+	}
+	return typ.(reflect2.SliceType).Elem()
+}
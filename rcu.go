@@ -7,10 +7,39 @@ import (
 	"unsafe"
 )
 
-// LinerRCU 依据 Read Copy Update 原理实现
+// rcuSlot 保存一个键的值，已发布到只读快照的键通过替换 slot 中的值原地覆盖，不必重建快照
+type rcuSlot struct {
+	p unsafe.Pointer // *any
+}
+
+func newRCUSlot(v any) *rcuSlot {
+	return &rcuSlot{p: unsafe.Pointer(&v)}
+}
+
+func (s *rcuSlot) load() any {
+	return *(*any)(atomic.LoadPointer(&s.p))
+}
+
+func (s *rcuSlot) store(v any) {
+	atomic.StorePointer(&s.p, unsafe.Pointer(&v))
+}
+
+// rcuPromoteAt 判断是否将新键合并进只读快照：新键个数与未命中快照的次数之和达到快照大小的一半时重建，
+// 重建的代价由此前的插入与查找分摊，插入的均摊代价为 O(1)
+func rcuPromoteAt(read, dirty, misses int) bool {
+	return dirty+misses > read/2
+}
+
+// LinerRCU 依据 Read Copy Update 原理实现：读取无锁访问只读快照，新键先写入加锁的 dirty，
+// 累计到一定数量后才合并进新的快照
 type LinerRCU struct {
 	lock sync.Mutex
 	m    unsafe.Pointer
+	// dirty 为尚未合并进快照的新键，由 lock 保护；amended 为 dirty 非空
+	dirty   map[reflect2.Type]*rcuSlot
+	amended uint32
+	misses  int
+	n       int64
 }
 
 func NewLinerRCU() (c *LinerRCU) {
@@ -24,39 +53,124 @@ func NewLinerRCU() (c *LinerRCU) {
 	}
 }
 
+func (c *LinerRCU) read() *linerMap {
+	return (*linerMap)(atomic.LoadPointer(&c.m))
+}
+
 func (c *LinerRCU) Load(key reflect2.Type) (v any, ok bool) {
-	m := (*linerMap)(atomic.LoadPointer(&c.m))
-	res := m.get(key)
-	return res, res != nil
+	if s := c.read().get(key); s != nil {
+		return s.load(), true
+	}
+	if atomic.LoadUint32(&c.amended) == 0 {
+		return nil, false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	s := c.slotLocked(key)
+	c.misses++
+	c.promoteLocked(false)
+	if s == nil {
+		return nil, false
+	}
+	return s.load(), true
 }
 
 func (c *LinerRCU) Store(key reflect2.Type, v any) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
-
-	m := (*linerMap)(atomic.LoadPointer(&c.m))
-	atomic.StorePointer(&c.m, unsafe.Pointer(m.add(key, v)))
+	if s := c.slotLocked(key); s != nil {
+		s.store(v)
+		return
+	}
+	c.insertLocked(key, v)
 }
 
 func (c *LinerRCU) LoadOrStore(key reflect2.Type, newV any) (v any, loaded bool) {
-	got, ok := c.Load(key)
-	if ok {
-		return got, true
+	if s := c.read().get(key); s != nil {
+		return s.load(), true
 	}
 
 	c.lock.Lock()
 	defer c.lock.Unlock()
 	// double check
-	m := (*linerMap)(atomic.LoadPointer(&c.m))
-	res := m.get(key)
-	if res != nil {
-		return res, true
+	if s := c.slotLocked(key); s != nil {
+		return s.load(), true
+	}
+	c.insertLocked(key, newV)
+	return newV, false
+}
+
+// slotLocked 依次在快照与 dirty 中查找 key，调用方需持有 lock
+func (c *LinerRCU) slotLocked(key reflect2.Type) *rcuSlot {
+	if s := c.read().get(key); s != nil {
+		return s
 	}
+	return c.dirty[key]
+}
 
-	m2 := m.add(key, newV)
-	atomic.StorePointer(&c.m, unsafe.Pointer(m2))
+// insertLocked 将新键写入 dirty，调用方需持有 lock
+func (c *LinerRCU) insertLocked(key reflect2.Type, v any) {
+	if c.dirty == nil {
+		c.dirty = make(map[reflect2.Type]*rcuSlot)
+		atomic.StoreUint32(&c.amended, 1)
+	}
+	c.dirty[key] = newRCUSlot(v)
+	atomic.AddInt64(&c.n, 1)
+	c.promoteLocked(false)
+}
 
-	return newV, false
+// promoteLocked 将 dirty 合并进新的快照，force 为 false 时只在达到重建条件时合并
+func (c *LinerRCU) promoteLocked(force bool) {
+	m := c.read()
+	if len(c.dirty) == 0 || !force && !rcuPromoteAt(int(atomic.LoadUint64(&m.n)), len(c.dirty), c.misses) {
+		return
+	}
+	r := m.grow(len(c.dirty))
+	for key, s := range c.dirty {
+		r.insert(key, s)
+	}
+	atomic.StorePointer(&c.m, unsafe.Pointer(r))
+	c.dirty, c.misses = nil, 0
+	atomic.StoreUint32(&c.amended, 0)
+}
+
+// Len returns the number of stored entries.
+func (c *LinerRCU) Len() int {
+	return int(atomic.LoadInt64(&c.n))
+}
+
+// Evict rebuilds the map with the entries for which keep returns true and
+// reports how many entries were dropped.
+func (c *LinerRCU) Evict(keep func(v any) bool) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.promoteLocked(true)
+	m := c.read()
+	r := &linerMap{m: m.m, b: make([]mapEntry, len(m.b))}
+	evicted := 0
+	for i := uint32(0); i <= m.m; i++ {
+		if b := m.b[i]; b.vt != nil {
+			if keep(b.fn.load()) {
+				r.insert(b.vt, b.fn)
+			} else {
+				evicted++
+			}
+		}
+	}
+	atomic.StorePointer(&c.m, unsafe.Pointer(r))
+	atomic.AddInt64(&c.n, -int64(evicted))
+	return evicted
+}
+
+// Reset drops all entries.
+func (c *LinerRCU) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	atomic.StorePointer(&c.m, NewLinerRCU().m)
+	c.dirty, c.misses = nil, 0
+	atomic.StoreUint32(&c.amended, 0)
+	atomic.StoreInt64(&c.n, 0)
 }
 
 /** 线性探测的开放寻址 Map **/
@@ -74,25 +188,23 @@ type linerMap struct {
 
 type mapEntry struct {
 	vt reflect2.Type
-	fn any
+	fn *rcuSlot
 }
 
-func (self *linerMap) copy() *linerMap {
-	fork := &linerMap{
-		n: self.n,
-		m: self.m,
-		b: make([]mapEntry, len(self.b)),
-	}
-	for i, f := range self.b {
-		fork.b[i] = f
-	}
-	return fork
+// hashRType 打散类型指针：指针低位按对齐恒为 0、高位集中于同一段内存，直接截断会让大量类型落在相邻槽位
+func hashRType(rtype uintptr) uint32 {
+	h := uint64(rtype)
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return uint32(h)
 }
 
-func (self *linerMap) get(vt reflect2.Type) any {
+func (self *linerMap) get(vt reflect2.Type) *rcuSlot {
 	i := self.m + 1
-	h := uint32(vt.RType())
-	p := h & self.m
+	p := hashRType(vt.RType()) & self.m
 
 	/* linear probing */
 	for ; i > 0; i-- {
@@ -109,23 +221,19 @@ func (self *linerMap) get(vt reflect2.Type) any {
 	return nil
 }
 
-func (self *linerMap) add(vt reflect2.Type, fn any) *linerMap {
-	p := self.copy()
-	f := float64(atomic.LoadUint64(&p.n)+1) / float64(p.m+1)
-
-	/* check for load factor */
-	if f > _LoadFactor {
-		p = p.rehash()
+// grow 返回可再容纳 extra 个元素的副本，超过负载因子时按 2 的幂扩容
+func (self *linerMap) grow(extra int) *linerMap {
+	n := atomic.LoadUint64(&self.n) + uint64(extra)
+	c := self.m + 1
+	for float64(n)/float64(c) > _LoadFactor {
+		c <<= 1
 	}
-
-	/* insert the value */
-	p.insert(vt, fn)
-	return p
-}
-
-func (self *linerMap) rehash() *linerMap {
-	c := (self.m + 1) << 1
 	r := &linerMap{m: c - 1, b: make([]mapEntry, int(c))}
+	if c == self.m+1 {
+		copy(r.b, self.b)
+		r.n = atomic.LoadUint64(&self.n)
+		return r
+	}
 
 	/* rehash every entry */
 	for i := uint32(0); i <= self.m; i++ {
@@ -133,18 +241,18 @@ func (self *linerMap) rehash() *linerMap {
 			r.insert(b.vt, b.fn)
 		}
 	}
-
-	/* rebuild successful */
 	return r
 }
 
-func (self *linerMap) insert(vt reflect2.Type, fn any) {
-	h := uint32(vt.RType())
-	p := h & self.m
+func (self *linerMap) insert(vt reflect2.Type, fn *rcuSlot) {
+	p := hashRType(vt.RType()) & self.m
 
-	/* linear probing */
+	/* linear probing, an existing key is overwritten */
 	for i := uint32(0); i <= self.m; i++ {
-		if b := &self.b[p]; b.vt != nil {
+		if b := &self.b[p]; b.vt == vt {
+			b.fn = fn
+			return
+		} else if b.vt != nil {
 			p += 1
 			p &= self.m
 		} else {
@@ -159,58 +267,139 @@ func (self *linerMap) insert(vt reflect2.Type, fn any) {
 	panic("no available slots")
 }
 
-// MapRCU 依据 Read Copy Update 原理实现
+// MapRCU 依据 Read Copy Update 原理实现，结构与 LinerRCU 相同：读取无锁访问只读快照，
+// 新键先写入加锁的 dirty，累计到一定数量后才合并进新的快照
 type MapRCU struct {
-	lock sync.Mutex
-	m    unsafe.Pointer
+	lock    sync.Mutex
+	m       unsafe.Pointer
+	dirty   map[[2]uintptr]*rcuSlot
+	amended uint32
+	misses  int
+	n       int64
 }
 
 func NewMapRCU() (c *MapRCU) {
-	hashMap := make(map[[2]uintptr]any, 10)
+	hashMap := make(map[[2]uintptr]*rcuSlot, 10)
 	return &MapRCU{
 		lock: sync.Mutex{},
 		m:    unsafe.Pointer(&hashMap),
 	}
 }
 
+func (c *MapRCU) read() map[[2]uintptr]*rcuSlot {
+	return *(*map[[2]uintptr]*rcuSlot)(atomic.LoadPointer(&c.m))
+}
+
 func (c *MapRCU) Load(key [2]uintptr) (v any, ok bool) {
-	m := *(*map[[2]uintptr]any)(atomic.LoadPointer(&c.m))
-	v, ok = m[key]
-	return v, ok
+	if s, ok := c.read()[key]; ok {
+		return s.load(), true
+	}
+	if atomic.LoadUint32(&c.amended) == 0 {
+		return nil, false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	s := c.slotLocked(key)
+	c.misses++
+	c.promoteLocked(false)
+	if s == nil {
+		return nil, false
+	}
+	return s.load(), true
 }
 
 func (c *MapRCU) Store(key [2]uintptr, v any) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	m := *(*map[[2]uintptr]any)(atomic.LoadPointer(&c.m))
-	newM := make(map[[2]uintptr]any, len(m)+1)
-	for k, v := range m {
-		newM[k] = v
+	if s := c.slotLocked(key); s != nil {
+		s.store(v)
+		return
 	}
-	atomic.StorePointer(&c.m, unsafe.Pointer(&newM))
+	c.insertLocked(key, v)
 }
 
 func (c *MapRCU) LoadOrStore(key [2]uintptr, newV any) (v any, loaded bool) {
-	got, ok := c.Load(key)
-	if ok {
-		return got, true
+	if s, ok := c.read()[key]; ok {
+		return s.load(), true
 	}
 
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	m := *(*map[[2]uintptr]any)(atomic.LoadPointer(&c.m))
-
 	// double check
-	v, ok = m[key]
-	if ok {
-		return v, true
+	if s := c.slotLocked(key); s != nil {
+		return s.load(), true
+	}
+	c.insertLocked(key, newV)
+	return newV, false
+}
+
+// slotLocked 依次在快照与 dirty 中查找 key，调用方需持有 lock
+func (c *MapRCU) slotLocked(key [2]uintptr) *rcuSlot {
+	if s, ok := c.read()[key]; ok {
+		return s
+	}
+	return c.dirty[key]
+}
+
+// insertLocked 将新键写入 dirty，调用方需持有 lock
+func (c *MapRCU) insertLocked(key [2]uintptr, v any) {
+	if c.dirty == nil {
+		c.dirty = make(map[[2]uintptr]*rcuSlot)
+		atomic.StoreUint32(&c.amended, 1)
 	}
+	c.dirty[key] = newRCUSlot(v)
+	atomic.AddInt64(&c.n, 1)
+	c.promoteLocked(false)
+}
 
-	newM := make(map[[2]uintptr]any, len(m)+1)
-	for k, v := range m {
-		newM[k] = v
+// promoteLocked 复制当前快照并合并 dirty，随后原子替换；force 为 false 时只在达到重建条件时合并
+func (c *MapRCU) promoteLocked(force bool) {
+	m := c.read()
+	if len(c.dirty) == 0 || !force && !rcuPromoteAt(len(m), len(c.dirty), c.misses) {
+		return
+	}
+	newM := make(map[[2]uintptr]*rcuSlot, len(m)+len(c.dirty))
+	for k, s := range m {
+		newM[k] = s
+	}
+	for k, s := range c.dirty {
+		newM[k] = s
 	}
-	newM[key] = newV
 	atomic.StorePointer(&c.m, unsafe.Pointer(&newM))
-	return newV, false
+	c.dirty, c.misses = nil, 0
+	atomic.StoreUint32(&c.amended, 0)
+}
+
+// Len returns the number of stored entries.
+func (c *MapRCU) Len() int {
+	return int(atomic.LoadInt64(&c.n))
+}
+
+// Evict rebuilds the map with the entries for which keep returns true and
+// reports how many entries were dropped.
+func (c *MapRCU) Evict(keep func(v any) bool) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.promoteLocked(true)
+	m := c.read()
+	newM := make(map[[2]uintptr]*rcuSlot, len(m))
+	for k, s := range m {
+		if keep(s.load()) {
+			newM[k] = s
+		}
+	}
+	atomic.StorePointer(&c.m, unsafe.Pointer(&newM))
+	evicted := len(m) - len(newM)
+	atomic.AddInt64(&c.n, -int64(evicted))
+	return evicted
+}
+
+// Reset drops all entries.
+func (c *MapRCU) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	atomic.StorePointer(&c.m, NewMapRCU().m)
+	c.dirty, c.misses = nil, 0
+	atomic.StoreUint32(&c.amended, 0)
+	atomic.StoreInt64(&c.n, 0)
 }
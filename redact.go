@@ -0,0 +1,98 @@
+package go_deep_copy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"unsafe"
+
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// RedactionPolicy decides what a Copier writes for struct fields tagged
+// `go_deep_copy:",sensitive"`, on either the source or the destination side.
+type RedactionPolicy int
+
+const (
+	// RedactNone copies sensitive fields like any other field.
+	RedactNone RedactionPolicy = iota
+	// RedactZero leaves the destination zeroed.
+	RedactZero
+	// RedactMask writes "***" into destinations that can hold a string, such
+	// as string, []byte and interface{}, and zeroes the others.
+	RedactMask
+	// RedactHash writes the hex SHA-256 of string and []byte sources into
+	// destinations that can hold a string, and zeroes the others.
+	RedactHash
+	// RedactDrop omits the key when copying into a map and zeroes struct
+	// fields.
+	RedactDrop
+)
+
+// RedactedMask is the value written by RedactMask.
+const RedactedMask = "***"
+
+// WithRedaction sets how the Copier handles sensitive fields, RedactNone by
+// default. It applies to struct to struct, struct to map and map to struct
+// copies, so cloning a value for logging is a single call:
+//
+//	type LoginRequest struct {
+//		User     string
+//		Password string `go_deep_copy:",sensitive"`
+//	}
+//
+//	logCopier := go_deep_copy.NewCopier(go_deep_copy.WithRedaction(go_deep_copy.RedactMask))
+func WithRedaction(policy RedactionPolicy) Option {
+	return func(c *Copier) {
+		c.redaction = policy
+	}
+}
+
+// redacts 判断敏感字段是否需要脱敏
+func (c *Copier) redacts(sensitive bool) bool {
+	return sensitive && c.redaction != RedactNone
+}
+
+// holdsString 判断 typ 能否承载脱敏后的字符串
+func holdsString(typ reflect2.Type) bool {
+	switch typ.Kind() {
+	case reflect.String:
+		return true
+	case reflect.Slice:
+		return typ.(reflect2.SliceType).Elem().Kind() == reflect.Uint8
+	case reflect.Interface:
+		return typ.Type1().NumMethod() == 0
+	}
+	return false
+}
+
+// redactOp 返回按脱敏策略写入 tType 的转换函数，源值只用于计算哈希
+func (c *Copier) redactOp(vType, tType reflect2.Type) ConvertFunc {
+	zero := func(v, t rt.Value) error {
+		t.Typ.UnsafeSet(t.Ptr, t.Typ.UnsafeNew())
+		return nil
+	}
+	hash := c.redaction == RedactHash
+	if (c.redaction != RedactMask && !hash) || !holdsString(tType) {
+		return zero
+	}
+	vKind := vType.Kind()
+	if hash && vKind != reflect.String && !(vKind == reflect.Slice && vType.(reflect2.SliceType).Elem().Kind() == reflect.Uint8) {
+		return zero
+	}
+	write := c.LoadConvertFunc(stringType, tType)
+	return func(v, t rt.Value) error {
+		s := RedactedMask
+		if hash {
+			var sum [sha256.Size]byte
+			if vKind == reflect.String {
+				sum = sha256.Sum256([]byte(*(*string)(v.Ptr)))
+			} else {
+				sum = sha256.Sum256(*(*[]byte)(v.Ptr))
+			}
+			s = hex.EncodeToString(sum[:])
+		}
+		return write(rt.Value{Ptr: unsafe.Pointer(&s), Typ: stringType}, t)
+	}
+}
@@ -0,0 +1,205 @@
+package go_deep_copy
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"unsafe"
+
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// implementations 记录某个接口的判别字段以及判别值与具体类型的对应关系
+type implementations struct {
+	field  string
+	byName map[string]reflect2.Type
+}
+
+// discriminator 记录具体类型拷贝到 map 时需要写入的判别字段
+type discriminator struct {
+	field string
+	name  string
+}
+
+var registry = struct {
+	sync.RWMutex
+	ifaces map[reflect.Type]*implementations
+	// 以结构体类型为键，指针实现按其元素类型登记
+	names map[reflect.Type]discriminator
+}{
+	ifaces: map[reflect.Type]*implementations{},
+	names:  map[reflect.Type]discriminator{},
+}
+
+// RegisterImplementations registers the concrete types of the interface I by
+// discriminator value. A map with string keys copied into an I destination
+// becomes the type registered under map[discriminatorField]; a registered
+// struct copied into a map gets discriminatorField set to its name.
+//
+//	RegisterImplementations[Shape]("type", map[string]Shape{
+//		"circle": Circle{},
+//		"square": &Square{},
+//	})
+//
+// Register implementations at init time, before the types are copied.
+func RegisterImplementations[I any](discriminatorField string, impls map[string]I) {
+	iface := reflect.TypeOf((*I)(nil)).Elem()
+	if iface.Kind() != reflect.Interface {
+		panic("go_deep_copy: RegisterImplementations requires an interface type, got " + iface.String())
+	}
+	registered := &implementations{
+		field:  discriminatorField,
+		byName: make(map[string]reflect2.Type, len(impls)),
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	for name, impl := range impls {
+		typ := reflect.TypeOf(impl)
+		if typ == nil {
+			panic("go_deep_copy: nil implementation registered for " + name)
+		}
+		registered.byName[name] = reflect2.Type2(typ)
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		registry.names[typ] = discriminator{field: discriminatorField, name: name}
+	}
+	registry.ifaces[iface] = registered
+}
+
+func loadImplementations(iface reflect.Type) *implementations {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.ifaces[iface]
+}
+
+func loadDiscriminator(typ reflect.Type) (discriminator, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	d, ok := registry.names[typ]
+	return d, ok
+}
+
+// mapToImplementationOp 返回 map[string]T -> 已登记接口的转换函数，按判别字段选择具体类型
+func (c *Copier) mapToImplementationOp(v, t reflect2.Type, impls *implementations) func(v, t rt.Value) error {
+	vType := v.(*reflect2.UnsafeMapType)
+	nameType := reflect2.TypeOf("")
+	nameConverter := c.LoadConvertFunc(vType.Elem(), nameType)
+	t1 := t.Type1()
+	names := make([]string, 0, len(impls.byName))
+	for name := range impls.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c.visitEdge("", "("+name+")", v, impls.byName[name])
+	}
+	return func(v, t rt.Value) error {
+		field := impls.field
+		// 缺少判别字段或其值为 nil 接口时无法选择具体类型
+		namePtr := vType.UnsafeGetIndex(v.Ptr, unsafe.Pointer(&field))
+		if namePtr == nil || vType.Elem().Kind() == reflect.Interface && vType.Elem().UnsafeIsNil(namePtr) {
+			return fmt.Errorf("%w: missing %s for %s", ErrUnknownImplementation, field, t1)
+		}
+		var name string
+		err := nameConverter(rt.Value{
+			Ptr: namePtr,
+			Typ: vType.Elem(),
+		}, rt.Value{
+			Ptr: unsafe.Pointer(&name),
+			Typ: nameType,
+		})
+		if err != nil {
+			return err
+		}
+		implType, ok := impls.byName[name]
+		if !ok {
+			return fmt.Errorf("%w: %s %q for %s", ErrUnknownImplementation, field, name, t1)
+		}
+		implPtr := c.newValue(implType)
+		err = c.LoadConvertFunc(v.Typ, implType)(v, rt.Value{
+			Ptr: implPtr,
+			Typ: implType,
+		})
+		if err != nil {
+			return err
+		}
+		reflect.NewAt(t1, t.Ptr).Elem().Set(reflect.ValueOf(implType.UnsafeIndirect(implPtr)))
+		return nil
+	}
+}
+
+// registeredToIfaceOp 返回 InterfaceWiden 模式下已登记接口的值及其切片、数组 -> interface{} 的转换函数：
+// 具体类型转换为带判别字段的 map[string]interface{}，切片转换为 []interface{}，与 map 拷贝到这些接口时的形式一致，
+// 使 map -> struct -> map 的往返对称；不涉及已登记接口时返回 nil
+func (c *Copier) registeredToIfaceOp(v reflect2.Type) func(v, t rt.Value) error {
+	if c.interfaceMode != InterfaceWiden {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		if loadImplementations(v.Type1()) != nil {
+			return c.cvtImplementationToI
+		}
+	case reflect.Slice, reflect.Array:
+		if elem := v.Type1().Elem(); elem.Kind() == reflect.Interface && loadImplementations(elem) != nil {
+			return c.cvtToJSONContainer(v, sliceIfaceType)
+		}
+	}
+	return nil
+}
+
+// cvtImplementationToI 将已登记接口中的具体类型转换为带判别字段的 map 存入 interface{}，未登记的类型与 nil 指针按 cvtIToI 处理
+func (c *Copier) cvtImplementationToI(v, t rt.Value) error {
+	dyn := unpackEFace(v.Typ.UnsafeIndirect(v.Ptr), v.St)
+	typ := dyn.Typ
+	if typ.Kind() == reflect.Ptr {
+		if *(*unsafe.Pointer)(dyn.Ptr) == nil {
+			return c.cvtIToI(v, t)
+		}
+		typ = typ.(*reflect2.UnsafePtrType).Elem()
+	}
+	if _, ok := loadDiscriminator(typ.Type1()); !ok {
+		return c.cvtIToI(v, t)
+	}
+	return c.cvtToJSONContainer(dyn.Typ, mapStringIfaceType)(dyn, t)
+}
+
+// structToMapOp 在 struct -> map 的基础上写入已登记类型的判别字段
+func (c *Copier) structToMapOp(v, t reflect2.Type) func(v, t rt.Value) error {
+	d, ok := loadDiscriminator(v.Type1())
+	tType := t.(*reflect2.UnsafeMapType)
+	if tType.Key().Kind() == reflect.String {
+		for _, f := range c.loadStructFieldsInfo(v).Fields {
+			c.visitEdge(f.Field.Name(), f.Field.Name(), f.Field.Type(), tType.Elem())
+		}
+	}
+	if !ok || tType.Key().Kind() != reflect.String {
+		return c.cvtStructToMap
+	}
+	nameType := reflect2.TypeOf("")
+	tElemType := tType.Elem()
+	nameConverter := c.LoadConvertFunc(nameType, tElemType)
+	return func(v, t rt.Value) error {
+		err := c.cvtStructToMap(v, t)
+		if err != nil {
+			return err
+		}
+		field, name := d.field, d.name
+		tElem := tElemType.UnsafeNew()
+		err = nameConverter(rt.Value{
+			Ptr: unsafe.Pointer(&name),
+			Typ: nameType,
+		}, rt.Value{
+			Ptr: tElem,
+			Typ: tElemType,
+		})
+		if err != nil {
+			return err
+		}
+		tType.UnsafeSetIndex(t.Ptr, unsafe.Pointer(&field), tElem)
+		return nil
+	}
+}
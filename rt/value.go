@@ -9,6 +9,20 @@ import (
 type Value struct {
 	Typ reflect2.Type
 	Ptr unsafe.Pointer
+	// St is the state of the copy the value belongs to. It is passed from a
+	// source value to its elements and is nil for copies without per copy checks.
+	St State
+}
+
+// State is shared by the values of a single copy, such as one with limits or
+// a context. Enter is called before a container or string value is copied and
+// aborts the copy with its error; Leave is called once the value is copied.
+// Tick is called before each element of a slice, array or map is copied and
+// aborts the copy with its error.
+type State interface {
+	Enter(v Value) error
+	Leave(v Value)
+	Tick() error
 }
 
 // SetBool sets v's underlying value.
@@ -0,0 +1,65 @@
+package go_deep_copy
+
+import (
+	"unsafe"
+
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// Snapshot is a lazy deep copy of a value. It shares the original until the
+// first call to Mutable, which deep copies it like Clone, so defensive copies
+// that are never written cost nothing.
+//
+// The original must not be modified while Snapshots share it. Snapshots of the
+// same original can be used from different goroutines, but a single Snapshot
+// is not safe for concurrent use.
+type Snapshot[T any] struct {
+	value *T
+	// copied 为 value 已是独立的拷贝
+	copied  bool
+	typ     reflect2.Type
+	cvtFunc ConvertFunc
+}
+
+// NewSnapshot returns a Snapshot sharing *value, or the zero T if value is
+// nil. The convert func of T is loaded from the cache when the Snapshot is
+// created.
+func NewSnapshot[T any](value *T) *Snapshot[T] {
+	if value == nil {
+		value = new(T)
+	}
+	typ := reflect2.TypeOf(value).(*reflect2.UnsafePtrType).Elem()
+	return &Snapshot[T]{
+		value:   value,
+		typ:     typ,
+		cvtFunc: cloneCopier.LoadConvertFunc(typ, typ),
+	}
+}
+
+// Load returns the current value: the original until Mutable is called, the
+// private copy after. It must not be modified; use Mutable to write.
+func (s *Snapshot[T]) Load() *T {
+	return s.value
+}
+
+// Mutable returns a private deep copy of the value that can be modified, making
+// it on the first call. Later calls return the same copy.
+func (s *Snapshot[T]) Mutable() (*T, error) {
+	if s.copied {
+		return s.value, nil
+	}
+	out := new(T)
+	err := s.cvtFunc(rt.Value{
+		Typ: s.typ,
+		Ptr: unsafe.Pointer(s.value),
+	}, rt.Value{
+		Typ: s.typ,
+		Ptr: unsafe.Pointer(out),
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.value, s.copied = out, true
+	return out, nil
+}
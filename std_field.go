@@ -60,6 +60,18 @@ type Binding struct {
 	levels []int
 	Field  reflect2.StructField
 	Name   string
+	// required 由标签选项 required 指定，缺少对应源字段或源值为 nil 时报错
+	required bool
+	// defaultValue 为标签选项 default=... 中的默认值，源值缺失或为 nil 时按 string -> T 转换后写入
+	defaultValue *string
+	// transforms 为标签选项 transform=a|b 中依次执行的转换名称
+	transforms []string
+	// hops 为匿名嵌入展开的字段从外层结构体到达所在结构体经过的嵌入字段
+	hops []fieldHop
+	// path 为从外层结构体到该字段的访问路径
+	path *fieldPath
+	// sensitive 为标签选项 sensitive，按 Copier 的脱敏策略处理
+	sensitive bool
 }
 
 func describeStruct(typ reflect2.Type) StructDescriptor {
@@ -72,12 +84,15 @@ func describeStruct(typ reflect2.Type) StructDescriptor {
 		if tag == "-" || field.Name() == "_" {
 			continue
 		}
-		tagParts := strings.Split(tag, ",")
-		if field.Anonymous() && (tag == "" || tagParts[0] == "") {
+		name, opts := parseTag(tag)
+		// 匿名嵌入的 sync.Mutex 等不可拷贝类型作为普通字段处理，避免展开其内部状态
+		if field.Anonymous() && name == "" && !isNoCopyType(field.Type()) {
 			if field.Type().Kind() == reflect.Struct {
 				structDescriptor := describeStruct(field.Type())
 				for _, binding := range structDescriptor.Fields {
 					binding.levels = append([]int{i}, binding.levels...)
+					// 展开后的字段偏移相对于嵌入的结构体，需先经过嵌入字段本身
+					binding.hops = append([]fieldHop{{offset: field.Offset()}}, binding.hops...)
 					embeddedBindings = append(embeddedBindings, binding)
 				}
 				continue
@@ -87,6 +102,7 @@ func describeStruct(typ reflect2.Type) StructDescriptor {
 					structDescriptor := describeStruct(ptrType.Elem())
 					for _, binding := range structDescriptor.Fields {
 						binding.levels = append([]int{i}, binding.levels...)
+						binding.hops = append([]fieldHop{{offset: field.Offset(), elem: ptrType.Elem()}}, binding.hops...)
 						embeddedBindings = append(embeddedBindings, binding)
 					}
 					continue
@@ -94,11 +110,19 @@ func describeStruct(typ reflect2.Type) StructDescriptor {
 			}
 		}
 		binding := &Binding{
-			Field: field,
-			Name:  field.Name(),
+			Field:     field,
+			Name:      field.Name(),
+			required:  opts.Contains("required"),
+			sensitive: opts.Contains("sensitive"),
 		}
-		if len(tag) > 0 {
-			binding.Name = tag
+		if value, ok := opts.Lookup("default"); ok {
+			binding.defaultValue = &value
+		}
+		if value, ok := opts.Lookup("transform"); ok && value != "" {
+			binding.transforms = strings.Split(value, "|")
+		}
+		if name != "" {
+			binding.Name = name
 		}
 		binding.levels = []int{i}
 		bindings = append(bindings, binding)
@@ -333,15 +357,16 @@ func dominantField(fields []field) (field, bool) {
 	return fields[0], true
 }
 
-func loadStructFieldsInfo(vt reflect2.Type) StructDescriptor {
-	if structInfo, ok := structInfoCache.Load(vt); ok {
-		return structInfo.(StructDescriptor)
+func (c *Copier) loadStructFieldsInfo(vt reflect2.Type) StructDescriptor {
+	if structInfo, ok := c.cache.loadStruct(vt); ok {
+		return structInfo
 	}
 	structInfo := describeStruct(vt)
 	structInfo.FieldMap = make(map[string]*Binding, len(structInfo.Fields))
 	for i := 0; i < len(structInfo.Fields); i++ {
+		structInfo.Fields[i].path = bindingPath(structInfo.Fields[i])
 		structInfo.FieldMap[structInfo.Fields[i].Name] = structInfo.Fields[i]
 	}
-	structInfoCache.Store(vt, structInfo)
+	c.cache.storeStruct(vt, structInfo)
 	return structInfo
 }
@@ -0,0 +1,57 @@
+package go_deep_copy
+
+import (
+	"reflect"
+
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// WithDisallowUnknownFields makes copies into structs fail with a *FieldsError
+// when the source struct has fields, or the source map has keys, that match no
+// destination field, like json.Decoder.DisallowUnknownFields.
+func WithDisallowUnknownFields() Option {
+	return func(c *Copier) {
+		c.disallowUnknownFields = true
+	}
+}
+
+// WithRequireAllFields treats every destination struct field as tagged
+// `go_deep_copy:",required"`: a missing source field or key, or a nil source
+// value, fails the copy with a *FieldsError.
+func WithRequireAllFields() Option {
+	return func(c *Copier) {
+		c.requireAllFields = true
+	}
+}
+
+func (c *Copier) isRequired(b *Binding) bool {
+	return b.required || c.requireAllFields
+}
+
+// isNilable 判断 typ 的值能否为 nil，nil 源值视同缺失
+func isNilable(typ reflect2.Type) bool {
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Chan, reflect.Func:
+		return true
+	}
+	return false
+}
+
+// structFieldsError 返回 struct -> struct 中与取值无关的字段错误：没有对应源字段的必填字段及没有对应目标字段的源字段
+func (c *Copier) structFieldsError(t reflect2.Type, unmatchedV, unmatchedT []*Binding) *FieldsError {
+	var missing, unknown []string
+	for _, tf := range unmatchedT {
+		if c.isRequired(tf) {
+			missing = append(missing, tf.Name)
+		}
+	}
+	if c.disallowUnknownFields {
+		for _, f := range unmatchedV {
+			unknown = append(unknown, f.Name)
+		}
+	}
+	if len(missing) == 0 && len(unknown) == 0 {
+		return nil
+	}
+	return &FieldsError{Type: t.Type1(), Missing: missing, Unknown: unknown}
+}
@@ -26,7 +26,7 @@ func (o tagOptions) Contains(optionName string) bool {
 	s := string(o)
 	for s != "" {
 		var name string
-		name, s, _ = strings.Cut(s, ",")
+		name, s = nextOption(s)
 		if name == optionName {
 			return true
 		}
@@ -34,6 +34,38 @@ func (o tagOptions) Contains(optionName string) bool {
 	return false
 }
 
+// Lookup returns the value of a key=value option, such as default=30s. A
+// value quoted with single quotes, such as default='a,b', may contain commas;
+// the quotes are removed.
+func (o tagOptions) Lookup(key string) (string, bool) {
+	s := string(o)
+	for s != "" {
+		var opt string
+		opt, s = nextOption(s)
+		if name, value, ok := strings.Cut(opt, "="); ok && name == key {
+			if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+				value = value[1 : len(value)-1]
+			}
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// nextOption 返回 s 中的第一个选项及其余部分，key='...' 形式的值中的逗号不作为分隔符；
+// 引号未闭合时按逗号拆分，值保留开头的引号
+func nextOption(s string) (opt, rest string) {
+	comma := strings.IndexByte(s, ',')
+	if eq := strings.IndexByte(s, '='); eq >= 0 && (comma < 0 || eq < comma) && strings.HasPrefix(s[eq+1:], "'") {
+		if end := strings.IndexByte(s[eq+2:], '\''); end >= 0 {
+			end += eq + 2
+			return s[:end+1], strings.TrimPrefix(s[end+1:], ",")
+		}
+	}
+	opt, rest, _ = strings.Cut(s, ",")
+	return opt, rest
+}
+
 func isValidTag(s string) bool {
 	if s == "" {
 		return false
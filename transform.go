@@ -0,0 +1,147 @@
+package go_deep_copy
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"github.com/LiZhiqiang0/go_deep_copy/rt"
+	"github.com/LiZhiqiang0/reflect2"
+)
+
+// transform 按名称登记的字段转换，from、to 为其输入输出类型
+type transform struct {
+	name string
+	from reflect2.Type
+	to   reflect2.Type
+	call func(v, t unsafe.Pointer) error
+}
+
+// WithTransform registers fn under name for the From -> To pair. Destination
+// struct fields reference transforms in their tag and run them, left to
+// right, on the source field during struct to struct copies:
+//
+//	type UserDTO struct {
+//		Email string `go_deep_copy:"Email,transform=trim|lower"`
+//		Phone string `go_deep_copy:"Phone,transform=mask"`
+//	}
+//
+// The same name may be registered for several pairs; the one accepting the
+// current value type is used. lower, upper and trim are registered for
+// strings by default. Unknown names and type mismatches fail when the plan is
+// compiled, see Precompile.
+func WithTransform[From, To any](name string, fn func(From) (To, error)) Option {
+	return func(c *Copier) {
+		c.addTransform(&transform{
+			name: name,
+			from: reflect2.TypeOf((*From)(nil)).(*reflect2.UnsafePtrType).Elem(),
+			to:   reflect2.TypeOf((*To)(nil)).(*reflect2.UnsafePtrType).Elem(),
+			call: func(v, t unsafe.Pointer) error {
+				out, err := fn(*(*From)(v))
+				if err != nil {
+					return err
+				}
+				*(*To)(t) = out
+				return nil
+			},
+		})
+	}
+}
+
+// stringTransform 将 func(string) string 包装为字段转换
+func stringTransform(fn func(string) string) func(string) (string, error) {
+	return func(s string) (string, error) {
+		return fn(s), nil
+	}
+}
+
+// builtinTransforms 每个 Copier 默认登记的字段转换
+var builtinTransforms = []Option{
+	WithTransform("lower", stringTransform(strings.ToLower)),
+	WithTransform("upper", stringTransform(strings.ToUpper)),
+	WithTransform("trim", stringTransform(strings.TrimSpace)),
+}
+
+func (c *Copier) addTransform(tr *transform) {
+	if c.transforms == nil {
+		c.transforms = map[string][]*transform{}
+	}
+	// 同名同类型的转换以后登记的为准
+	registered := c.transforms[tr.name]
+	for i, old := range registered {
+		if old.from.RType() == tr.from.RType() && old.to.RType() == tr.to.RType() {
+			registered[i] = tr
+			return
+		}
+	}
+	c.transforms[tr.name] = append(registered, tr)
+}
+
+// lookupTransform 返回名为 name、输入类型为 from 的转换，有多个时优先输出类型为 to 的
+func (c *Copier) lookupTransform(name string, from, to reflect2.Type) (*transform, error) {
+	registered, ok := c.transforms[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTransform, name)
+	}
+	var found *transform
+	for _, tr := range registered {
+		if tr.from.RType() != from.RType() {
+			continue
+		}
+		if to != nil && tr.to.RType() == to.RType() {
+			return tr, nil
+		}
+		if found == nil {
+			found = tr
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: %q does not accept %s", ErrUnknownTransform, name, from.Type1())
+	}
+	return found, nil
+}
+
+// transformOp 按目标字段标签中的 transform 选项构建 vType -> 字段类型的转换函数，未声明时返回 nil
+func (c *Copier) transformOp(owner reflect2.Type, tf *Binding, vType reflect2.Type) (ConvertFunc, error) {
+	if len(tf.transforms) == 0 {
+		return nil, nil
+	}
+	tType := tf.Field.Type()
+	steps := make([]*transform, 0, len(tf.transforms))
+	cur := vType
+	for i, name := range tf.transforms {
+		want := tType
+		if i < len(tf.transforms)-1 {
+			want = nil
+		}
+		tr, err := c.lookupTransform(name, cur, want)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", owner.Type1(), tf.Name, err)
+		}
+		steps = append(steps, tr)
+		cur = tr.to
+	}
+	field := tf.Name
+	// 最后一步输出类型与字段类型一致时直接写入目标，否则再按常规规则转换
+	var final ConvertFunc
+	if cur.RType() != tType.RType() {
+		final = c.LoadConvertFunc(cur, tType)
+	}
+	return func(v, t rt.Value) error {
+		ptr := v.Ptr
+		for i, tr := range steps {
+			next := t.Ptr
+			if i < len(steps)-1 || final != nil {
+				next = c.newValue(tr.to)
+			}
+			if err := tr.call(ptr, next); err != nil {
+				return fmt.Errorf("transform %s of %s: %w", tr.name, field, err)
+			}
+			ptr = next
+		}
+		if final != nil {
+			return final(rt.Value{Ptr: ptr, Typ: cur}, t)
+		}
+		return nil
+	}, nil
+}